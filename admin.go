@@ -0,0 +1,671 @@
+package main
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Handle "!adjust @user ±points [±days]"
+func (b *Bot) handleAdjustCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can adjust scores.")
+		return
+	}
+
+	usage := fmt.Sprintf("Usage: `%sadjust @user +N` or `%[1]sadjust @user -N`, optionally followed by a days played change like `+1`", b.prefix)
+	args := commandArgs(m.Content)
+	if len(args) < 2 || len(args) > 3 {
+		sendMessage(s, m.ChannelID, usage)
+		return
+	}
+	userID := cleanUsername(args[0])
+	scoreDelta, err := strconv.Atoi(args[1])
+	if err != nil {
+		sendMessage(s, m.ChannelID, usage)
+		return
+	}
+	daysDelta := 0
+	if len(args) == 3 {
+		if daysDelta, err = strconv.Atoi(args[2]); err != nil {
+			sendMessage(s, m.ChannelID, usage)
+			return
+		}
+	}
+	if scoreDelta == 0 && daysDelta == 0 {
+		sendMessage(s, m.ChannelID, "Nothing to adjust.")
+		return
+	}
+
+	total, days, err := b.adjustScore(b.boardFor(m.GuildID, m.ChannelID), userID, m.Author.ID, scoreDelta, daysDelta)
+	if err == sql.ErrNoRows {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("%s isn't on the leaderboard.", mention(userID)))
+		return
+	}
+	if err != nil {
+		slog.Error("Error adjusting score", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to adjust the score, nothing was changed.")
+		return
+	}
+	sendMessage(s, m.ChannelID, fmt.Sprintf("Adjusted %s: score %+d (now %d), days played %+d (now %d).", mention(userID), scoreDelta, total, daysDelta, days))
+}
+
+// Apply a manual correction to a user's totals and record it in the
+// adjustments table. Returns the new total score and days played, or
+// sql.ErrNoRows if the user has no leaderboard entry.
+func (b *Bot) adjustScore(bd board, userID, adminID string, scoreDelta, daysDelta int) (int, int, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("starting adjustment: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	var total, days int
+	err = tx.QueryRow("SELECT score, days_played FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", bd.guildID, bd.channelID, bd.game, userID).Scan(&total, &days)
+	if err != nil {
+		return 0, 0, err
+	}
+	total += scoreDelta
+	days = max(days+daysDelta, 0)
+
+	_, err = tx.Exec("UPDATE leaderboard SET score = ?, days_played = ? WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", total, days, bd.guildID, bd.channelID, bd.game, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("updating %s: %w", userID, err)
+	}
+	_, err = tx.Exec("INSERT INTO adjustments (guild_id, channel_id, game, user_id, admin_id, score_delta, days_delta) VALUES (?, ?, ?, ?, ?, ?, ?)", bd.guildID, bd.channelID, bd.game, userID, adminID, scoreDelta, daysDelta)
+	if err != nil {
+		return 0, 0, fmt.Errorf("recording adjustment: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("committing adjustment: %w", err)
+	}
+
+	slog.Info("Score adjusted", "guild", bd.guildID, "channel", bd.channelID, "user", userID, "admin", adminID, "score_delta", scoreDelta, "days_delta", daysDelta)
+	return total, days, nil
+}
+
+// Handle "!undo": show what the last processed day would revert, and revert
+// it once repeated as "!undo confirm"
+func (b *Bot) handleUndoCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can undo processed results.")
+		return
+	}
+
+	if args := commandArgs(m.Content); len(args) == 0 || !strings.EqualFold(args[0], "confirm") {
+		day, users, err := b.previewUndo(b.boardFor(m.GuildID, m.ChannelID))
+		if err == sql.ErrNoRows {
+			sendMessage(s, m.ChannelID, "There are no processed results to undo.")
+			return
+		}
+		if err != nil {
+			slog.Error("Error previewing undo", "err", err)
+			return
+		}
+		sendMessage(s, m.ChannelID, fmt.Sprintf("This will revert the results for %s and affect %d user(s). Run `%sundo confirm` to go ahead.", describeDay(day), users, b.prefix))
+		return
+	}
+
+	day, users, err := b.undoLastDay(b.boardFor(m.GuildID, m.ChannelID))
+	if err == sql.ErrNoRows {
+		sendMessage(s, m.ChannelID, "There are no processed results to undo.")
+		return
+	}
+	if err != nil {
+		slog.Error("Error undoing last day", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to undo the last results, nothing was changed.")
+		return
+	}
+	slog.Info("Undid processed day", "guild", m.GuildID, "channel", m.ChannelID, "admin", m.Author.ID, "puzzle", day.puzzle, "date", day.date, "users", users)
+	sendMessage(s, m.ChannelID, fmt.Sprintf("Reverted the results for %s for %d user(s). The results message can now be processed again.", describeDay(day), users))
+}
+
+// "Wordle 1234 (2026-10-13)", or just the date when the puzzle is unknown
+func describeDay(day resultDay) string {
+	if day.puzzle > 0 {
+		return fmt.Sprintf("Wordle %d (%s)", day.puzzle, day.date)
+	}
+	return day.date
+}
+
+// Find the most recently processed day in a guild, or sql.ErrNoRows.
+// SQLite's processed_at has whole seconds, so of days processed in the same
+// second the one whose results were recorded last wins.
+func lastProcessedDay(q interface {
+	QueryRow(query string, args ...any) *sql.Row
+}, bd board) (resultDay, error) {
+	var day resultDay
+	err := q.QueryRow(`
+    SELECT p.puzzle_number, p.result_date FROM processed_days p
+    WHERE p.guild_id = ? AND p.channel_id = ? AND p.game = ?
+    ORDER BY p.processed_at DESC,
+             COALESCE((SELECT MAX(d.id) FROM daily_results d
+                       WHERE d.guild_id = p.guild_id AND d.channel_id = p.channel_id AND d.game = p.game
+                         AND d.result_date = p.result_date AND d.puzzle_number = p.puzzle_number), 0) DESC
+    LIMIT 1`, bd.guildID, bd.channelID, bd.game).Scan(&day.puzzle, &day.date)
+	return day, err
+}
+
+// Count the users !undo would touch
+func (b *Bot) previewUndo(bd board) (resultDay, int, error) {
+	day, err := lastProcessedDay(b.db, bd)
+	if err != nil {
+		return day, 0, err
+	}
+	var users int
+	err = b.db.QueryRow("SELECT COUNT(*) FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND result_date = ? AND puzzle_number = ?", bd.guildID, bd.channelID, bd.game, day.date, day.puzzle).Scan(&users)
+	return day, users, err
+}
+
+// Revert the most recently processed day using its daily_results rows: take
+// back each score, played day and fail, recompute streaks, and forget the day
+// so its message can be processed again. Returns sql.ErrNoRows if nothing has
+// been processed.
+func (b *Bot) undoLastDay(bd board) (resultDay, int, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return resultDay{}, 0, fmt.Errorf("starting undo: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	day, err := lastProcessedDay(tx, bd)
+	if err != nil {
+		return day, 0, err
+	}
+
+	type result struct {
+		userID                string
+		score, played, failed int
+	}
+	rows, err := tx.Query("SELECT user_id, "+b.scoring.dailyColumn()+", played, failed FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND result_date = ? AND puzzle_number = ?", bd.guildID, bd.channelID, bd.game, day.date, day.puzzle)
+	if err != nil {
+		return day, 0, fmt.Errorf("loading results to undo: %w", err)
+	}
+	var results []result
+	for rows.Next() {
+		var r result
+		if err := rows.Scan(&r.userID, &r.score, &r.played, &r.failed); err != nil {
+			rows.Close()
+			return day, 0, fmt.Errorf("scanning result to undo: %w", err)
+		}
+		results = append(results, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return day, 0, fmt.Errorf("loading results to undo: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND result_date = ? AND puzzle_number = ?", bd.guildID, bd.channelID, bd.game, day.date, day.puzzle)
+	if err != nil {
+		return day, 0, fmt.Errorf("deleting undone results: %w", err)
+	}
+
+	for _, r := range results {
+		var currentStreak, maxStreak int
+		err := tx.QueryRow("SELECT current_streak, max_streak FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", bd.guildID, bd.channelID, bd.game, r.userID).Scan(&currentStreak, &maxStreak)
+		if err == sql.ErrNoRows {
+			continue // No totals to revert
+		}
+		if err != nil {
+			return day, 0, fmt.Errorf("loading %s: %w", r.userID, err)
+		}
+
+		replayedStreak, replayedMax, err := replayStreaks(tx, bd, r.userID)
+		if err != nil {
+			return day, 0, err
+		}
+		// A played day that set the best streak raised it by one. History may
+		// not reach back to when streaks started, so only trust it to raise
+		// the max back up.
+		if r.played == 1 && maxStreak == currentStreak {
+			maxStreak = max(maxStreak-1, replayedMax)
+		}
+
+		_, err = tx.Exec(`
+    UPDATE leaderboard
+    SET score = score - ?, days_played = CASE WHEN days_played > ? THEN days_played - ? ELSE 0 END,
+        fails = CASE WHEN fails > ? THEN fails - ? ELSE 0 END,
+        current_streak = ?, max_streak = ?, joined_date = `+playedDateSQL("MIN")+`, last_played = `+playedDateSQL("MAX")+`
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?`, r.score, r.played, r.played, r.failed, r.failed, replayedStreak, maxStreak, bd.guildID, bd.channelID, bd.game, r.userID)
+		if err != nil {
+			return day, 0, fmt.Errorf("reverting %s: %w", r.userID, err)
+		}
+
+		// Users who first appeared in the undone message shouldn't stay on
+		// the board collecting absence penalties
+		_, err = tx.Exec(`
+    DELETE FROM leaderboard
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND score = 0 AND days_played = 0
+      AND NOT EXISTS (SELECT 1 FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?)`, bd.guildID, bd.channelID, bd.game, r.userID, bd.guildID, bd.channelID, bd.game, r.userID)
+		if err != nil {
+			return day, 0, fmt.Errorf("removing empty entry for %s: %w", r.userID, err)
+		}
+	}
+
+	_, err = tx.Exec("DELETE FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND result_date = ? AND puzzle_number = ?", bd.guildID, bd.channelID, bd.game, day.date, day.puzzle)
+	if err != nil {
+		return day, 0, fmt.Errorf("forgetting processed day: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return day, 0, fmt.Errorf("committing undo: %w", err)
+	}
+	return day, len(results), nil
+}
+
+// Recompute a user's current and best streak from their daily_results
+// history, using the same rules as updateCumulativeScore, vacations
+// included
+func replayStreaks(tx *sql.Tx, bd board, userID string) (int, int, error) {
+	rows, err := tx.Query("SELECT result_date, played FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? ORDER BY result_date, id", bd.guildID, bd.channelID, bd.game, userID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("loading history for %s: %w", userID, err)
+	}
+	type result struct {
+		date   string
+		played int
+	}
+	var history []result
+	for rows.Next() {
+		var r result
+		if err := rows.Scan(&r.date, &r.played); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("scanning history for %s: %w", userID, err)
+		}
+		history = append(history, r)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("loading history for %s: %w", userID, err)
+	}
+
+	current, best := 0, 0
+	lastPlayed := ""
+	for _, r := range history {
+		if r.played == 0 {
+			current = 0 // Missed day breaks the streak
+			continue
+		}
+		previous, err := streakPreviousDate(tx, bd, userID, r.date)
+		if err != nil {
+			return 0, 0, err
+		}
+		if lastPlayed != "" && lastPlayed == previous {
+			current++
+		} else {
+			current = 1
+		}
+		lastPlayed = r.date
+		best = max(best, current)
+	}
+	return current, best, nil
+}
+
+// Handle "!export": upload the all-time leaderboard as a CSV attachment
+func (b *Bot) handleExportCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can export the leaderboard.")
+		return
+	}
+
+	data, err := b.exportCSV(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error exporting leaderboard", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to export the leaderboard.")
+		return
+	}
+	name := fmt.Sprintf("leaderboard-%s.csv", b.now().Format(dateLayout))
+	sendFile(s, m.ChannelID, name, data)
+}
+
+// Render the all-time leaderboard as CSV; encoding/csv quotes usernames
+// containing commas, quotes or newlines
+func (b *Bot) exportCSV(bd board) ([]byte, error) {
+	entries, err := b.leaderboardEntries(bd, allTimePeriod)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"rank", "user_id", "username", "score", "days_played", "average"})
+	for i, rank := range competitionRanks(entries) {
+		e := entries[i]
+		w.Write([]string{
+			strconv.Itoa(rank),
+			e.userID,
+			e.username,
+			strconv.Itoa(e.totalScore),
+			strconv.Itoa(e.daysPlayed),
+			e.shownAverage(),
+		})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// Handle "!merge @from @to": fold a duplicate entry into the user it belongs to
+func (b *Bot) handleMergeCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can merge users.")
+		return
+	}
+
+	args := commandArgs(m.Content)
+	if len(args) != 2 {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%smerge @from @to`", b.prefix))
+		return
+	}
+	fromID, toID := cleanUsername(args[0]), cleanUsername(args[1])
+	if fromID == toID {
+		sendMessage(s, m.ChannelID, "Pick two different users to merge.")
+		return
+	}
+
+	total, days, err := b.mergeUsers(b.boardFor(m.GuildID, m.ChannelID), fromID, toID)
+	if err == sql.ErrNoRows {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Both %s and %s need to be on the leaderboard.", mention(fromID), mention(toID)))
+		return
+	}
+	if err != nil {
+		slog.Error("Error merging users", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to merge the users, nothing was changed.")
+		return
+	}
+	slog.Info("Users merged", "guild", m.GuildID, "channel", m.ChannelID, "from", fromID, "to", toID, "admin", m.Author.ID)
+	sendMessage(s, m.ChannelID, fmt.Sprintf("Merged %s into %s: score now %d over %d days played.", mention(fromID), mention(toID), total, days))
+}
+
+// Move one user's totals and history onto another and delete the source
+// entry, in one transaction. Where both have a result for the same day only
+// one is kept, preferring a played day over an absence penalty, so a day is
+// never counted twice. Returns the target's new score and days played, or
+// sql.ErrNoRows if either user has no leaderboard entry.
+func (b *Bot) mergeUsers(bd board, fromID, toID string) (int, int, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("starting merge: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	type totals struct{ score, days, fails, maxStreak int }
+	var from, to totals
+	for _, u := range []struct {
+		id string
+		t  *totals
+	}{{fromID, &from}, {toID, &to}} {
+		err := tx.QueryRow("SELECT score, days_played, fails, max_streak FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", bd.guildID, bd.channelID, bd.game, u.id).Scan(&u.t.score, &u.t.days, &u.t.fails, &u.t.maxStreak)
+		if err != nil {
+			return 0, 0, err
+		}
+	}
+
+	// Days both users have a result for; drop the weaker of each pair
+	column := b.scoring.dailyColumn()
+	rows, err := tx.Query(`
+    SELECT f.id, f.`+column+`, f.played, f.failed, t.id, t.`+column+`, t.played, t.failed
+    FROM daily_results f
+    JOIN daily_results t ON t.guild_id = f.guild_id AND t.channel_id = f.channel_id AND t.game = f.game AND t.result_date = f.result_date
+    WHERE f.guild_id = ? AND f.channel_id = ? AND f.game = ? AND f.user_id = ? AND t.user_id = ?`, bd.guildID, bd.channelID, bd.game, fromID, toID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("finding overlapping days: %w", err)
+	}
+	var dropIDs []int64
+	var dropped totals
+	for rows.Next() {
+		var fromRow, toRow struct {
+			id                    int64
+			score, played, failed int
+		}
+		if err := rows.Scan(&fromRow.id, &fromRow.score, &fromRow.played, &fromRow.failed, &toRow.id, &toRow.score, &toRow.played, &toRow.failed); err != nil {
+			rows.Close()
+			return 0, 0, fmt.Errorf("scanning overlapping day: %w", err)
+		}
+		drop := fromRow
+		if fromRow.played > toRow.played {
+			drop = toRow
+		}
+		dropIDs = append(dropIDs, drop.id)
+		dropped.score += drop.score
+		dropped.days += drop.played
+		dropped.fails += drop.failed
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, fmt.Errorf("finding overlapping days: %w", err)
+	}
+	for _, id := range dropIDs {
+		if _, err := tx.Exec("DELETE FROM daily_results WHERE id = ?", id); err != nil {
+			return 0, 0, fmt.Errorf("dropping overlapping day: %w", err)
+		}
+	}
+
+	// Move the remaining history, adjustments included, onto the target
+	for _, table := range []string{"daily_results", "adjustments"} {
+		_, err := tx.Exec("UPDATE "+table+" SET user_id = ? WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", toID, bd.guildID, bd.channelID, bd.game, fromID)
+		if err != nil {
+			return 0, 0, fmt.Errorf("moving %s: %w", table, err)
+		}
+	}
+
+	total := to.score + from.score - dropped.score
+	days := max(to.days+from.days-dropped.days, 0)
+	fails := max(to.fails+from.fails-dropped.fails, 0)
+	currentStreak, replayedMax, err := replayStreaks(tx, bd, toID)
+	if err != nil {
+		return 0, 0, err
+	}
+	maxStreak := max(to.maxStreak, from.maxStreak, replayedMax)
+
+	_, err = tx.Exec(`
+    UPDATE leaderboard
+    SET score = ?, days_played = ?, fails = ?, current_streak = ?, max_streak = ?,
+        joined_date = `+playedDateSQL("MIN")+`, last_played = `+playedDateSQL("MAX")+`
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?`, total, days, fails, currentStreak, maxStreak, bd.guildID, bd.channelID, bd.game, toID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("updating %s: %w", toID, err)
+	}
+	_, err = tx.Exec("DELETE FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", bd.guildID, bd.channelID, bd.game, fromID)
+	if err != nil {
+		return 0, 0, fmt.Errorf("deleting %s: %w", fromID, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("committing merge: %w", err)
+	}
+	return total, days, nil
+}
+
+// Handle "!dupes": list entries that look like the same person
+func (b *Bot) handleDupesCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can look for duplicate users.")
+		return
+	}
+
+	groups, err := b.findDuplicates(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error looking for duplicate users", "err", err)
+		return
+	}
+	if len(groups) == 0 {
+		sendMessage(s, m.ChannelID, "No likely duplicates found.")
+		return
+	}
+
+	output := "👥 **Likely duplicates**\n"
+	for _, g := range groups {
+		output += fmt.Sprintf("**%s**: %s\n", g.name, strings.Join(g.entries, ", "))
+	}
+	output += fmt.Sprintf("Combine a pair with `%smerge @from @to`.", b.prefix)
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Entries on a board that share a name, ignoring case
+type duplicateGroup struct {
+	name    string
+	entries []string // each as "`user_id` (score/days)"
+}
+
+// Group a board's entries by case-insensitive name. Entries left over from
+// before users were keyed on their Discord ID have the name as their user ID,
+// so that counts as a name too.
+func (b *Bot) findDuplicates(bd board) ([]duplicateGroup, error) {
+	rows, err := b.db.Query("SELECT user_id, username, score, days_played FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? ORDER BY user_id", bd.guildID, bd.channelID, bd.game)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byName := make(map[string]*duplicateGroup)
+	var order []string
+	for rows.Next() {
+		var userID, username string
+		var score, days int
+		if err := rows.Scan(&userID, &username, &score, &days); err != nil {
+			return nil, err
+		}
+		names := []string{username}
+		if !isUserID(userID) {
+			names = append(names, userID) // Legacy name-keyed entry
+		}
+
+		seen := make(map[string]bool)
+		for _, name := range names {
+			key := strings.ToLower(strings.TrimSpace(name))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			g := byName[key]
+			if g == nil {
+				g = &duplicateGroup{name: strings.TrimSpace(name)}
+				byName[key] = g
+				order = append(order, key)
+			}
+			g.entries = append(g.entries, fmt.Sprintf("`%s` (%d/%d)", userID, score, days))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	var groups []duplicateGroup
+	for _, key := range order {
+		if g := byName[key]; len(g.entries) > 1 {
+			groups = append(groups, *g)
+		}
+	}
+	return groups, nil
+}
+
+// Dry-run the results parser on the message an admin replies to, or on a
+// "> " quoted copy of one after the command, and report what it extracted
+// without recording anything
+func (b *Bot) handleParseCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can dry-run the results parser.")
+		return
+	}
+
+	target := m.ReferencedMessage
+	if target == nil && m.MessageReference != nil {
+		msg, err := s.ChannelMessage(m.MessageReference.ChannelID, m.MessageReference.MessageID)
+		if err != nil {
+			slog.Error("Error fetching message to parse", "err", err)
+			sendMessage(s, m.ChannelID, "Couldn't fetch the message you replied to.")
+			return
+		}
+		target = msg
+	}
+	if target == nil {
+		quoted := unquote(commandText(m.Content))
+		if quoted == "" {
+			sendMessage(s, m.ChannelID, fmt.Sprintf("Reply to a Wordle results message with `%sparse`, or quote one after the command.", b.prefix))
+			return
+		}
+		copied := *m.Message
+		copied.Content = quoted
+		target = &copied
+	}
+	if target.GuildID == "" {
+		target.GuildID = m.GuildID // referenced messages don't carry it
+	}
+
+	sendMessage(s, m.ChannelID, b.parseReport(s, target))
+}
+
+// Strip the "> " markers from quoted lines
+func unquote(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		line = strings.TrimPrefix(line, ">>> ")
+		line = strings.TrimPrefix(line, "> ")
+		lines[i] = strings.TrimPrefix(line, ">")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// Describe what processing a results message would record, as a code block
+func (b *Bot) parseReport(s *discordgo.Session, msg *discordgo.Message) string {
+	scores, names, mode := b.extractScores(s, msg)
+	day := resultDay{date: b.puzzleDate(msg), puzzle: parsePuzzleNumber(msg.Content)}
+
+	puzzle := "none found"
+	if day.puzzle > 0 {
+		puzzle = strconv.Itoa(day.puzzle)
+	}
+	output := fmt.Sprintf("Puzzle: %s\nDate:   %s\nMode:   %s\n", puzzle, day.date, mode)
+	if b.alreadyProcessed(b.boardFor(msg.GuildID, msg.ChannelID), day) {
+		output += "(this day is already recorded, the message would be skipped)\n"
+	}
+
+	if len(scores) == 0 {
+		output += "No scores found.\n"
+	} else {
+		userIDs := make([]string, 0, len(scores))
+		for userID := range scores {
+			userIDs = append(userIDs, userID)
+		}
+		// Best first, with "?/6" results after the fails
+		rank := func(userID string) int {
+			if scores[userID] == unknownScore {
+				return failScore + 1
+			}
+			return scores[userID]
+		}
+		sort.Slice(userIDs, func(i, j int) bool {
+			if rank(userIDs[i]) != rank(userIDs[j]) {
+				return rank(userIDs[i]) < rank(userIDs[j])
+			}
+			return userIDs[i] < userIDs[j]
+		})
+		output += fmt.Sprintf("Scores (%d):\n", len(scores))
+		for _, userID := range userIDs {
+			line := fmt.Sprintf("  %s %s", scoreResult(scores[userID]), userID)
+			if names[userID] != "" {
+				line += fmt.Sprintf(" (%s)", names[userID])
+			}
+			if b.excludedUser(msg.GuildID, userID) {
+				line += " - excluded, ignored"
+			}
+			output += line + "\n"
+		}
+	}
+	// Backticks in names would end the code block early
+	return "```\n" + strings.ReplaceAll(output, "`", "'") + "```"
+}
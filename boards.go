@@ -0,0 +1,31 @@
+package main
+
+// The group a leaderboard belongs to. Without WATCHED_CHANNEL_IDS every guild
+// has a single board (channelID ""); with it, each channel keeps its own.
+// Each game tracked has its own board in the same place.
+type board struct {
+	guildID   string
+	channelID string
+	game      string // name of a registered game, defaultGame unless another is named
+}
+
+// The Wordle board for a guild channel: the channel itself when per-channel
+// leaderboards are on, otherwise the whole guild. Commands run outside a
+// watched channel therefore see an empty board.
+func (b *Bot) boardFor(guildID, channelID string) board {
+	if len(b.watchedChannels) == 0 {
+		return board{guildID: guildID, game: defaultGame}
+	}
+	return board{guildID: guildID, channelID: channelID, game: defaultGame}
+}
+
+// The same board for another game
+func (bd board) forGame(name string) board {
+	bd.game = name
+	return bd
+}
+
+// Whether results posted in a channel should be recorded
+func (b *Bot) watchesChannel(channelID string) bool {
+	return len(b.watchedChannels) == 0 || b.watchedChannels[channelID]
+}
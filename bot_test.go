@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestMain(m *testing.M) {
+	// Migrations and results log at info level; keep test output readable
+	slog.SetDefault(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Exit(m.Run())
+}
+
+// A bot on a fresh, empty database, schema and statements ready. The
+// backend is in-memory SQLite, or Postgres with -tags postgres.
+func newTestBot(t *testing.T) *Bot {
+	t.Helper()
+	b := newBot(newTestStore(t))
+	b.loc = time.UTC
+	if err := b.initializeDatabase(); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.prepareStatements(); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// The date of the nth test day, counting from 2026-01-01 as day 0
+func testDate(n int) string {
+	return time.Date(2026, 1, 1+n, 0, 0, 0, 0, time.UTC).Format(dateLayout)
+}
+
+// Stands in for the Discord API: every request succeeds with an empty
+// reply, and the content of each message sent is kept in order
+type offlineDiscord struct {
+	mu   sync.Mutex
+	sent []string
+}
+
+func (d *offlineDiscord) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.Method == http.MethodPost && r.Body != nil {
+		var msg struct{ Content string }
+		if json.NewDecoder(r.Body).Decode(&msg) == nil && msg.Content != "" {
+			d.mu.Lock()
+			d.sent = append(d.sent, msg.Content)
+			d.mu.Unlock()
+		}
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    r,
+	}, nil
+}
+
+// The messages sent so far
+func (d *offlineDiscord) messages() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return slices.Clone(d.sent)
+}
+
+// A Discord session talking to an offlineDiscord instead of Discord
+func offlineSession(t *testing.T) (*discordgo.Session, *offlineDiscord) {
+	t.Helper()
+	s, err := discordgo.New("Bot test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &offlineDiscord{}
+	s.Client = &http.Client{Transport: d}
+	s.State.User = &discordgo.User{ID: "bot"}
+	return s, d
+}
+
+// Record a results message for each of days on consecutive days from
+// testDate(0), as user ID -> score
+func recordDays(t *testing.T, b *Bot, bd board, days ...map[string]int) {
+	t.Helper()
+	for i, scores := range days {
+		if err := b.updateScoresBasedOnResults(bd, scores, map[string]string{}, resultDay{date: testDate(i)}); err != nil {
+			t.Fatalf("recording day %d: %v", i, err)
+		}
+	}
+}
+
+// One player's line on a leaderboard
+type standing struct {
+	userID            string
+	total, days, rank int
+}
+
+// A board's leaderboard for a period, best first
+func standings(t *testing.T, b *Bot, bd board, period leaderboardPeriod) []standing {
+	t.Helper()
+	entries, err := b.leaderboardEntries(bd, period)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make([]standing, len(entries))
+	for i, rank := range competitionRanks(entries) {
+		got[i] = standing{entries[i].userID, entries[i].totalScore, entries[i].daysPlayed, rank}
+	}
+	return got
+}
+
+func TestStandings(t *testing.T) {
+	// Each skips one day with an unknown result: both average 3.50 over 2
+	// days, 111 winning the one day both played and 222 playing last
+	splitTie := []map[string]int{
+		{"111": 3, "222": 4},
+		{"111": 4, "222": unknownScore},
+		{"111": unknownScore, "222": 3},
+	}
+	tests := []struct {
+		name  string
+		setup func(t *testing.T, b *Bot, bd board) // run before the days are recorded
+		days  []map[string]int
+		want  []standing
+	}{
+		{
+			name: "other guilds kept apart",
+			setup: func(t *testing.T, b *Bot, bd board) {
+				recordDays(t, b, b.boardFor("other-guild", ""), map[string]int{"111": 5, "333": 2})
+			},
+			// 222 isn't penalized for the other guild's day either
+			days: []map[string]int{{"111": 3, "222": 4}},
+			want: []standing{{"111", 3, 1, 1}, {"222", 4, 1, 2}},
+		},
+		{
+			name: "three-way tie at the top",
+			days: []map[string]int{
+				{"111": 3, "222": 2, "333": 4, "444": 4, "555": 5},
+				{"111": 3, "222": 4, "333": 2, "444": 5, "555": 5},
+			},
+			want: []standing{{"111", 6, 2, 1}, {"222", 6, 2, 1}, {"333", 6, 2, 1}, {"444", 9, 2, 4}, {"555", 10, 2, 5}},
+		},
+		{
+			// 222 beat 111 on two of their three days, so goes first though
+			// they still share the rank
+			name: "tie ordered by head-to-head",
+			days: []map[string]int{
+				{"111": 2, "222": 4},
+				{"111": 5, "222": 4},
+				{"111": 5, "222": 4},
+			},
+			want: []standing{{"222", 12, 3, 1}, {"111", 12, 3, 1}},
+		},
+		{
+			// 111 and 222 both average 3.50 as shown, 111 over fewer days;
+			// 333 joins between them. Nobody is penalized for days before
+			// they joined.
+			name: "equal-looking averages, more days first",
+			days: []map[string]int{
+				{"222": 3},
+				{"222": 4, "333": 3},
+				{"222": 3, "333": 3, "111": 3},
+				{"222": 4, "333": 4, "111": 4},
+			},
+			want: []standing{{"333", 10, 3, 1}, {"222", 14, 4, 2}, {"111", 7, 2, 2}},
+		},
+		{
+			name: "equal averages by head-to-head by default",
+			days: splitTie,
+			want: []standing{{"111", 7, 2, 1}, {"222", 7, 2, 1}},
+		},
+		{
+			name: "recent tiebreak puts the latest player first",
+			setup: func(t *testing.T, b *Bot, bd board) {
+				b.scoring.tiebreak = tiebreakRecent
+			},
+			days: splitTie,
+			want: []standing{{"222", 7, 2, 1}, {"111", 7, 2, 1}},
+		},
+		{
+			// 222 joins on day 2 and misses day 3; days 0 and 1 are
+			// processed afterwards and don't count against them
+			name: "no penalties before joining",
+			setup: func(t *testing.T, b *Bot, bd board) {
+				for i, scores := range []map[string]int{{"111": 3, "222": 4}, {"111": 3}} {
+					if err := b.updateScoresBasedOnResults(bd, scores, map[string]string{}, resultDay{date: testDate(2 + i)}); err != nil {
+						t.Fatal(err)
+					}
+				}
+			},
+			days: []map[string]int{{"111": 3}, {"111": 3}},
+			want: []standing{{"111", 12, 4, 1}, {"222", 4 + failScore, 1, 2}},
+		},
+		{
+			// 222 is away on day 1 and isn't penalized for missing it
+			name: "no penalty on vacation",
+			setup: func(t *testing.T, b *Bot, bd board) {
+				if _, err := b.db.Exec("INSERT INTO vacations (guild_id, user_id, start_date, end_date) VALUES (?, ?, ?, ?)", bd.guildID, "222", testDate(1), testDate(1)); err != nil {
+					t.Fatal(err)
+				}
+			},
+			days: []map[string]int{
+				{"111": 3, "222": 4},
+				{"111": 4},
+				{"111": 3, "222": 3},
+			},
+			want: []standing{{"111", 10, 3, 1}, {"222", 7, 2, 2}},
+		},
+		{
+			// "?/6" scores nothing and isn't a miss either, nor does it put
+			// anyone new on the board
+			name: "unknown result skipped",
+			days: []map[string]int{
+				{"111": 3, "222": 4},
+				{"111": 2, "222": unknownScore, "333": unknownScore},
+			},
+			want: []standing{{"111", 5, 2, 1}, {"222", 4, 1, 2}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newTestBot(t)
+			bd := b.boardFor("guild", "")
+			if tt.setup != nil {
+				tt.setup(t, b, bd)
+			}
+			recordDays(t, b, bd, tt.days...)
+			if got := standings(t, b, bd, allTimePeriod); !slices.Equal(got, tt.want) {
+				t.Errorf("standings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,510 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Prefix used when COMMAND_PREFIX is unset or invalid
+const defaultCommandPrefix = "!"
+
+// Validate the configured command prefix. Surrounding whitespace is trimmed,
+// and an empty prefix or one containing spaces falls back to the default.
+// The prefix is compared as plain text ignoring case, so characters such as
+// "$", "." or "?" are taken literally and need no escaping.
+func parseCommandPrefix(prefix string) string {
+	prefix = strings.TrimSpace(prefix)
+	if prefix == "" || strings.ContainsFunc(prefix, unicode.IsSpace) {
+		return defaultCommandPrefix
+	}
+	return prefix
+}
+
+// Short forms people type for commands, always accepted on top of any
+// configured in COMMAND_ALIASES
+var defaultAliases = map[string]string{
+	"lb":           "leaderboard",
+	"leaderboards": "leaderboard",
+	"board":        "leaderboard",
+}
+
+// Parse COMMAND_ALIASES ("alias=command, ...") on top of the default
+// aliases. Every alias must name a registered command and must not shadow one.
+func parseCommandAliases(raw string) (map[string]string, error) {
+	aliases := maps.Clone(defaultAliases)
+	for _, entry := range strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' }) {
+		alias, name, ok := strings.Cut(strings.ToLower(entry), "=")
+		if !ok || alias == "" || name == "" {
+			return nil, fmt.Errorf("COMMAND_ALIASES entry %q must look like alias=command", entry)
+		}
+		if _, ok := lookupCommand(alias); ok {
+			return nil, fmt.Errorf("COMMAND_ALIASES alias %q is already a command", alias)
+		}
+		if _, ok := lookupCommand(name); !ok {
+			return nil, fmt.Errorf("COMMAND_ALIASES entry %q names unknown command %q", entry, name)
+		}
+		aliases[alias] = name
+	}
+	return aliases, nil
+}
+
+// The registered command with exactly this name
+func lookupCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+	return command{}, false
+}
+
+// Split a message into its first word and the rest, both trimmed. A command
+// message's first word is the prefixed command name.
+func splitCommand(content string) (word, rest string) {
+	content = strings.TrimSpace(content)
+	end := strings.IndexFunc(content, unicode.IsSpace)
+	if end < 0 {
+		return content, ""
+	}
+	return content[:end], strings.TrimSpace(content[end:])
+}
+
+// The whitespace separated arguments after a command word
+func commandArgs(content string) []string {
+	_, rest := splitCommand(content)
+	return strings.Fields(rest)
+}
+
+// Everything after a command word as typed, line breaks included
+func commandText(content string) string {
+	_, rest := splitCommand(content)
+	return rest
+}
+
+// The command a message invokes: its first word must be the prefix followed
+// by a command name or alias, ignoring case. "!leaderboardxyz" invokes nothing.
+func (b *Bot) findCommand(content string) (command, bool) {
+	word, _ := splitCommand(content)
+	name, ok := strings.CutPrefix(strings.ToLower(word), strings.ToLower(b.prefix))
+	if !ok || name == "" {
+		return command{}, false
+	}
+	if canonical, ok := b.aliases[name]; ok {
+		name = canonical
+	}
+	return lookupCommand(name)
+}
+
+// Handle received messages
+func (b *Bot) onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
+	// Ignore the bot's own messages
+	if m.Author.ID == s.State.User.ID {
+		return
+	}
+
+	// Run the command the message invokes, if any, unless the user ran it
+	// too recently; they're told once and ignored after that. Admins aren't
+	// held back on admin commands, whose "confirm" step follows right away.
+	if cmd, ok := b.findCommand(m.Content); ok {
+		cooldown := b.cooldown
+		if cmd.adminOnly && b.isAdmin(s, m) {
+			cooldown = 0
+		}
+		if left, warn := b.cooldowns.allow(m.Author.ID, cmd.name, cooldown, time.Now()); left > 0 {
+			if warn {
+				sendMessage(s, m.ChannelID, fmt.Sprintf("Slow down <@%s>, you can use `%s%s` again in %ds.", m.Author.ID, b.prefix, cmd.name, int(left.Seconds()+0.999)))
+			}
+		} else {
+			b.metrics.countCommand(cmd.name)
+			cmd.handler(b, s, m)
+		}
+	}
+
+	// Message content is user data, so it is only logged at debug level
+	slog.Debug("Message received", "author", m.Author.ID, "channel", m.ChannelID, "content", m.Content)
+
+	if g, ok := b.detectGame(m.Message); ok {
+		if !b.watchesChannel(m.ChannelID) {
+			slog.Debug("Results message ignored, channel is not watched", "game", g.name, "channel", m.ChannelID)
+		} else {
+			slog.Info("Processing results message", "game", g.name, "guild", m.GuildID, "channel", m.ChannelID, "message", m.ID)
+			b.processResultsMessage(s, m.Message, g)
+		}
+	} else {
+		if strings.Contains(strings.ToLower(m.Content), "results") {
+			slog.Debug("Message ignored, not from the Wordle bot", "author", m.Author.ID)
+		}
+	}
+}
+
+// Display the leaderboard (all-time, "week" or "month", optionally "page N")
+// of Wordle or the game named
+func (b *Bot) handleLeaderboardCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	game, args := gameArg(commandArgs(m.Content))
+	bd := b.boardFor(m.GuildID, m.ChannelID).forGame(game)
+	period, page, ok := b.leaderboardArgs(s, m, bd, args)
+	if !ok {
+		return
+	}
+	if page == 0 {
+		b.sendLeaderboard(s, m.ChannelID, bd, period)
+		return
+	}
+	embed, err := b.buildLeaderboardPage(bd, period, page)
+	if err != nil {
+		slog.Error("Error fetching leaderboard", "err", err)
+		return
+	}
+	sendEmbed(s, m.ChannelID, embed)
+}
+
+// Parse leaderboard arguments for a board, telling the user what's wrong with
+// a bad date. Standings as of a date start from the season it fell in.
+func (b *Bot) leaderboardArgs(s *discordgo.Session, m *discordgo.MessageCreate, bd board, args []string) (leaderboardPeriod, int, bool) {
+	period, page, err := parseLeaderboardArgs(args, b.now())
+	if err != nil {
+		sendMessage(s, m.ChannelID, err.Error())
+		return period, page, false
+	}
+	if period.asOf() {
+		if period.since, err = b.seasonStartBefore(bd, period.until); err != nil {
+			slog.Error("Error fetching leaderboard", "err", err)
+			return period, page, false
+		}
+	}
+	return period, page, true
+}
+
+// Display the wins leaderboard: days solved, most first, fewer games
+// breaking ties. Takes the same game, period and page arguments as
+// !leaderboard.
+func (b *Bot) handleWinsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	game, args := gameArg(commandArgs(m.Content))
+	bd := b.boardFor(m.GuildID, m.ChannelID).forGame(game)
+	period, page, ok := b.leaderboardArgs(s, m, bd, args)
+	if !ok {
+		return
+	}
+	period.sort = sortWins
+	if page == 0 {
+		b.sendLeaderboard(s, m.ChannelID, bd, period)
+		return
+	}
+	embed, err := b.buildLeaderboardPage(bd, period, page)
+	if err != nil {
+		slog.Error("Error fetching wins leaderboard", "err", err)
+		return
+	}
+	sendEmbed(s, m.ChannelID, embed)
+}
+
+// Display the top current streaks of Wordle or the game named
+func (b *Bot) handleStreaksCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	game, _ := gameArg(commandArgs(m.Content))
+	output, err := b.buildStreaks(b.boardFor(m.GuildID, m.ChannelID).forGame(game))
+	if err != nil {
+		slog.Error("Error fetching streaks", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display one player's stats
+func (b *Bot) handleStatsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	args := commandArgs(m.Content)
+	if len(args) == 0 {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%sstats @user`", b.prefix))
+		return
+	}
+	output, err := b.buildUserStats(b.boardFor(m.GuildID, m.ChannelID), cleanUsername(args[0]))
+	if err != nil {
+		slog.Error("Error fetching user stats", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display where a player, or the caller, sits on the all-time leaderboard
+func (b *Bot) handleRankCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	userID := m.Author.ID
+	if args := commandArgs(m.Content); len(args) > 0 {
+		userID = cleanUsername(args[0])
+	}
+	output, err := b.buildRank(b.boardFor(m.GuildID, m.ChannelID), userID, userID == m.Author.ID)
+	if err != nil {
+		slog.Error("Error fetching rank", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Compare two players side by side: "!compare @a @b", or "!compare @user"
+// to compare yourself with them
+func (b *Bot) handleCompareCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	args := commandArgs(m.Content)
+	var userID, otherID string
+	switch len(args) {
+	case 1:
+		userID, otherID = m.Author.ID, cleanUsername(args[0])
+	case 2:
+		userID, otherID = cleanUsername(args[0]), cleanUsername(args[1])
+	default:
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%scompare @player @player`", b.prefix))
+		return
+	}
+	if userID == otherID {
+		sendMessage(s, m.ChannelID, "Pick two different players to compare.")
+		return
+	}
+
+	embed, reply, err := b.buildCompare(b.boardFor(m.GuildID, m.ChannelID), userID, otherID)
+	if err != nil {
+		slog.Error("Error comparing players", "err", err)
+		return
+	}
+	if embed == nil {
+		sendMessage(s, m.ChannelID, reply)
+		return
+	}
+	sendEmbed(s, m.ChannelID, embed)
+}
+
+// Display the biggest rank climbers and droppers since the previous day
+func (b *Bot) handleMoversCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	output, err := b.buildMovers(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error fetching movers", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display a player's recent scores as a sparkline: "!history [@user] [days]"
+func (b *Bot) handleHistoryCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	userID := m.Author.ID
+	days := defaultHistoryDays
+	for _, arg := range commandArgs(m.Content) {
+		if n, err := strconv.Atoi(arg); err == nil && len(arg) <= 3 { // Longer numbers are user IDs
+			days = min(max(n, 1), maxHistoryDays)
+		} else {
+			userID = cleanUsername(arg)
+		}
+	}
+	output, err := b.buildHistory(b.boardFor(m.GuildID, m.ChannelID), userID, days)
+	if err != nil {
+		slog.Error("Error fetching history", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display everyone's result for one puzzle: "!puzzle 1203"
+func (b *Bot) handlePuzzleCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	args := commandArgs(m.Content)
+	puzzle := 0
+	if len(args) > 0 {
+		puzzle, _ = strconv.Atoi(strings.ReplaceAll(strings.TrimPrefix(args[0], "#"), ",", ""))
+	}
+	if puzzle <= 0 {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%spuzzle <number>`", b.prefix))
+		return
+	}
+	output, err := b.buildPuzzle(b.boardFor(m.GuildID, m.ChannelID), puzzle)
+	if err != nil {
+		slog.Error("Error fetching puzzle results", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display how often a player, or the caller, solved in each number of guesses
+func (b *Bot) handleDistributionCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	userID := m.Author.ID
+	if args := commandArgs(m.Content); len(args) > 0 {
+		userID = cleanUsername(args[0])
+	}
+	output, err := b.buildDistribution(b.boardFor(m.GuildID, m.ChannelID), userID)
+	if err != nil {
+		slog.Error("Error fetching guess distribution", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display aggregate trivia about the board
+func (b *Bot) handleServerStatsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	output, err := b.buildServerStats(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error fetching server stats", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display the results recorded today
+func (b *Bot) handleTodayCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	output, err := b.buildToday(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error fetching today's results", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Ping the players who haven't played today
+func (b *Bot) handlePendingCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	output, err := b.buildPending(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error fetching pending players", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Display the caller's own stats
+func (b *Bot) handleMyStatsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	output, err := b.buildMyStats(b.boardFor(m.GuildID, m.ChannelID), m.Author.ID)
+	if err != nil {
+		slog.Error("Error fetching user stats", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Check whether a message comes from the Wordle bot, posted by the bot itself
+// or relayed through a webhook
+func (b *Bot) isWordleMessage(msg *discordgo.Message) bool {
+	if msg.WebhookID != "" {
+		return b.isWordleWebhook(msg)
+	}
+	return b.isWordleBot(msg.Author)
+}
+
+// Check whether a webhook message relays the Wordle bot's results. Its author
+// is the webhook (webhook ID, webhook name, no discriminator), so it is
+// matched on WORDLE_WEBHOOK_IDS when set, otherwise on a webhook named
+// "Wordle" or a "Wordle No. 1,234" header in the content.
+func (b *Bot) isWordleWebhook(msg *discordgo.Message) bool {
+	if len(b.wordleWebhookIDs) > 0 {
+		return b.wordleWebhookIDs[msg.WebhookID]
+	}
+	return strings.EqualFold(msg.Author.Username, "Wordle") || parsePuzzleNumber(msg.Content) > 0
+}
+
+// Check whether a message author is the Wordle bot, or another account on
+// the WORDLE_BOTS allowlist (Wordle#2092 unless configured)
+func (b *Bot) isWordleBot(author *discordgo.User) bool {
+	for _, bot := range b.wordleBots {
+		if bot.matches(author) {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle "!exclude @user" and "!include @user"
+func (b *Bot) handleExclusionCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only server admins can manage the exclusion list.")
+		return
+	}
+
+	args := commandArgs(m.Content)
+	if len(args) == 0 {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%[1]sexclude @user` or `%[1]sinclude @user`", b.prefix))
+		return
+	}
+	cmd, _ := b.findCommand(m.Content)
+	exclude := cmd.name == "exclude"
+	userID := cleanUsername(args[0])
+
+	var err error
+	var reply string
+	b.writeMu.Lock()
+	if exclude {
+		// An admin exclusion replaces an opt-out, so !optin can't lift it
+		_, err = b.db.Exec("INSERT INTO excluded_users (guild_id, user_id) VALUES (?, ?) ON CONFLICT (guild_id, user_id) DO UPDATE SET opted_out = 0", m.GuildID, userID)
+		reply = fmt.Sprintf("%s is now excluded from the leaderboard.", mention(userID))
+	} else {
+		_, err = b.db.Exec("DELETE FROM excluded_users WHERE guild_id = ? AND user_id = ?", m.GuildID, userID)
+		reply = fmt.Sprintf("%s is no longer excluded from the leaderboard.", mention(userID))
+	}
+	b.writeMu.Unlock()
+	if err != nil {
+		slog.Error("Error updating exclusion list", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to update the exclusion list.")
+		return
+	}
+	sendMessage(s, m.ChannelID, reply)
+}
+
+// Handle "!optout" and "!optin": let players stop or resume being scored.
+// Opted-out players are treated like excluded ones, so their history stays
+// and comes back when they opt in again.
+func (b *Bot) handleOptOutCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	cmd, _ := b.findCommand(m.Content)
+	optOut := cmd.name == "optout"
+
+	b.writeMu.Lock()
+	var res sql.Result
+	var err error
+	if optOut {
+		res, err = b.db.Exec("INSERT INTO excluded_users (guild_id, user_id, opted_out) VALUES (?, ?, 1) ON CONFLICT DO NOTHING", m.GuildID, m.Author.ID)
+	} else {
+		res, err = b.db.Exec("DELETE FROM excluded_users WHERE guild_id = ? AND user_id = ? AND opted_out = 1", m.GuildID, m.Author.ID)
+	}
+	b.writeMu.Unlock()
+	if err != nil {
+		slog.Error("Error updating opt-out", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to update your opt-out, please try again.")
+		return
+	}
+
+	changed, _ := res.RowsAffected()
+	switch {
+	case optOut && changed > 0:
+		sendMessage(s, m.ChannelID, fmt.Sprintf("<@%s> you've opted out: your results won't be scored and you won't get penalties. Your history is kept, use `%soptin` to come back.", m.Author.ID, b.prefix))
+	case optOut:
+		sendMessage(s, m.ChannelID, fmt.Sprintf("<@%s> you're already not being scored.", m.Author.ID))
+	case changed > 0:
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Welcome back <@%s>, your results will be scored again.", m.Author.ID))
+	default:
+		// Either never opted out, or excluded by an admin, which only they can undo
+		sendMessage(s, m.ChannelID, fmt.Sprintf("<@%s> you weren't opted out. If an admin excluded you, ask them to `%sinclude` you.", m.Author.ID, b.prefix))
+	}
+}
+
+// Check whether the message author may run admin commands: anyone listed in
+// ADMIN_USER_IDS, or with the Manage Server permission
+func (b *Bot) isAdmin(s *discordgo.Session, m *discordgo.MessageCreate) bool {
+	if b.adminIDs[m.Author.ID] {
+		return true
+	}
+	perms, err := s.UserChannelPermissions(m.Author.ID, m.ChannelID)
+	if err != nil {
+		slog.Error("Error fetching user permissions", "err", err)
+		return false
+	}
+	return perms&discordgo.PermissionManageServer != 0
+}
+
+// Check whether a user is on a guild's exclusion list
+func (b *Bot) isExcluded(tx *sql.Tx, guildID, userID string) (bool, error) {
+	var exists int
+	err := tx.Stmt(b.stmts.isExcluded).QueryRow(guildID, userID).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking exclusion list: %w", err)
+	}
+	return true, nil
+}
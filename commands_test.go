@@ -0,0 +1,187 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestFindCommand(t *testing.T) {
+	b := newBot(nil)
+	tests := []struct {
+		content string
+		want    string // the command name, "" for none
+	}{
+		{"!leaderboard", "leaderboard"},
+		{"!leaderboard week", "leaderboard"},
+		{"  !leaderboard\tpage 2", "leaderboard"},
+		{"!LEADERBOARD", "leaderboard"},
+		{"!lb", "leaderboard"},
+		{"!stats", "stats"},
+		{"!leaderboardmania", ""},
+		{"!leaderboard-please", ""},
+		{"!leaderboard.", ""},
+		{"!statsplease", ""},
+		{"!lbx", ""},
+		{"leaderboard", ""},
+		{"! leaderboard", ""},
+		{"!", ""},
+		{"", ""},
+		{"check the !leaderboard", ""},
+	}
+	for _, tt := range tests {
+		cmd, ok := b.findCommand(tt.content)
+		if got := cmd.name; ok != (tt.want != "") || got != tt.want {
+			t.Errorf("findCommand(%q) = %q, %v, want %q", tt.content, got, ok, tt.want)
+		}
+	}
+}
+
+func TestFindCommandCustomPrefix(t *testing.T) {
+	b := newBot(nil)
+	b.prefix = "wb!"
+	if cmd, ok := b.findCommand("wb!leaderboard"); !ok || cmd.name != "leaderboard" {
+		t.Errorf("findCommand with prefix %q = %q, %v, want leaderboard", b.prefix, cmd.name, ok)
+	}
+	if _, ok := b.findCommand("!leaderboard"); ok {
+		t.Errorf("findCommand matched the default prefix with %q set", b.prefix)
+	}
+}
+
+func TestCommandArgs(t *testing.T) {
+	tests := []struct {
+		content string
+		want    []string
+	}{
+		{"!leaderboard", nil},
+		{"!leaderboard week", []string{"week"}},
+		{"!leaderboard  page   2 ", []string{"page", "2"}},
+		{"!leaderboard\nmonth", []string{"month"}},
+	}
+	for _, tt := range tests {
+		if got := commandArgs(tt.content); !slices.Equal(got, tt.want) {
+			t.Errorf("commandArgs(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestCooldownSparesAdminConfirm(t *testing.T) {
+	b := newTestBot(t)
+	b.adminIDs = map[string]bool{"admin": true}
+	s, discord := offlineSession(t)
+	recordDays(t, b, b.boardFor("guild", "channel"), map[string]int{"111": 3})
+	send := func(authorID, content string) {
+		b.onMessageCreate(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			GuildID:   "guild",
+			ChannelID: "channel",
+			Content:   content,
+			Author:    &discordgo.User{ID: authorID},
+		}})
+	}
+
+	// The confirm step right after the preview goes through for an admin
+	send("admin", "!reset")
+	send("admin", "!reset confirm")
+	var seasons int
+	if err := b.db.QueryRow("SELECT COUNT(*) FROM seasons").Scan(&seasons); err != nil {
+		t.Fatal(err)
+	}
+	if seasons != 1 {
+		t.Errorf("%d seasons archived after !reset confirm, want 1", seasons)
+	}
+
+	// Anyone else is still held to the cooldown, admin command or not
+	send("user", "!reset")
+	send("user", "!reset confirm")
+	send("user", "!reset confirm")
+	slowDowns := 0
+	for _, msg := range discord.messages() {
+		if strings.HasPrefix(msg, "Slow down <@user>") {
+			slowDowns++
+		}
+	}
+	if slowDowns != 1 {
+		t.Errorf("user was told to slow down %d times, want 1", slowDowns)
+	}
+}
+
+func TestIsWordleMessageFromWebhook(t *testing.T) {
+	// A relayed message's author is the webhook: its ID, the webhook's
+	// name and no discriminator
+	webhook := func(id, name, content string) *discordgo.Message {
+		return &discordgo.Message{
+			WebhookID: id,
+			Author:    &discordgo.User{ID: id, Username: name, Discriminator: "0000", Bot: true},
+			Content:   content,
+		}
+	}
+	tests := []struct {
+		name       string
+		webhookIDs map[string]bool
+		msg        *discordgo.Message
+		want       bool
+	}{
+		{"named Wordle", nil, webhook("900", "Wordle", "3/6: <@111>"), true},
+		{"named wordle", nil, webhook("900", "wordle", "3/6: <@111>"), true},
+		{"puzzle header", nil, webhook("900", "Relay", "Wordle No. 1,203\n3/6: <@111>"), true},
+		{"other webhook", nil, webhook("900", "Relay", "3/6: <@111>"), false},
+		{"configured ID", map[string]bool{"900": true}, webhook("900", "Relay", "3/6: <@111>"), true},
+		{"unconfigured ID", map[string]bool{"900": true}, webhook("901", "Wordle", "Wordle No. 1,203"), false},
+		{"bot itself", nil, &discordgo.Message{Author: &discordgo.User{ID: "800", Username: "Wordle", Discriminator: "2092", Bot: true}}, true},
+		{"user named Wordle", nil, &discordgo.Message{Author: &discordgo.User{ID: "801", Username: "Wordle", Discriminator: "0"}, Content: "Wordle No. 1,203"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newBot(nil)
+			b.wordleWebhookIDs = tt.webhookIDs
+			if got := b.isWordleMessage(tt.msg); got != tt.want {
+				t.Errorf("isWordleMessage = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordleBotsAllowlist(t *testing.T) {
+	official := &discordgo.User{ID: "800", Username: "Wordle", Discriminator: "2092", Bot: true}
+	mock := &discordgo.User{ID: "900", Username: "mockwordle", Discriminator: "0", Bot: true}
+	tagged := &discordgo.User{ID: "901", Username: "Staging", Discriminator: "1234", Bot: true}
+	tests := []struct {
+		name     string
+		bots, id string // WORDLE_BOTS and WORDLE_BOT_USER_ID
+		accepted []*discordgo.User
+		refused  []*discordgo.User
+	}{
+		{"default", "", "", []*discordgo.User{official}, []*discordgo.User{mock, tagged}},
+		{"bare name and tag", "mockwordle, Staging#1234", "", []*discordgo.User{mock, tagged}, []*discordgo.User{official}},
+		{"user IDs", "900", "800", []*discordgo.User{official, mock}, []*discordgo.User{tagged}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WORDLE_BOTS", tt.bots)
+			t.Setenv("WORDLE_BOT_USER_ID", tt.id)
+			b := newBot(nil)
+			var err error
+			if b.wordleBots, err = loadWordleBots(); err != nil {
+				t.Fatal(err)
+			}
+			for _, u := range tt.accepted {
+				if !b.isWordleBot(u) {
+					t.Errorf("%s#%s refused", u.Username, u.Discriminator)
+				}
+			}
+			for _, u := range tt.refused {
+				if b.isWordleBot(u) {
+					t.Errorf("%s#%s accepted", u.Username, u.Discriminator)
+				}
+			}
+		})
+	}
+
+	for _, entry := range []string{"Wordle#209", "#2092", "Wordle#abcd"} {
+		if _, err := parseBotIdentity(entry); err == nil {
+			t.Errorf("WORDLE_BOTS entry %q accepted", entry)
+		}
+	}
+}
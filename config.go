@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// An optional on/off setting read from the environment
+type toggle struct {
+	name  string
+	value *bool
+}
+
+// Optional behaviour switches, all off unless set to true
+func (b *Bot) toggles() []toggle {
+	return []toggle{
+		{"BARE_NAME_FALLBACK", &b.bareNames},
+		{"WOODEN_SPOON", &b.woodenSpoon},
+		{"LEADERBOARD_EDIT_IN_PLACE", &b.editLeaderboards},
+		{"GRID_CHECK", &b.gridCheck},
+		{"NO_RESULTS_ACK", &b.noResultsAck},
+		{"NO_RESULTS_LEADERBOARD", &b.noResultsLeaderboard},
+		{"TRACK_DEPARTURES", &b.trackDepartures},
+		{"LEADERBOARD_PINGS", &b.leaderboardPings},
+	}
+}
+
+// Check the environment before anything is started, returning every problem
+// found so they can be fixed in one go. importing relaxes the settings only
+// the Discord connection needs.
+func checkEnvironment(importing bool) []string {
+	var problems []string
+
+	if !importing && strings.TrimSpace(os.Getenv("DISCORD_BOT_TOKEN")) == "" {
+		problems = append(problems, "DISCORD_BOT_TOKEN is not set")
+	}
+
+	url := strings.TrimSpace(os.Getenv("DATABASE_URL"))
+	switch driver := dbDriver(); driver {
+	case driverSQLite:
+		if url != "" {
+			problems = append(problems, "DATABASE_URL is set but DB_DRIVER is not postgres")
+		}
+		if poolSettingsSet() {
+			problems = append(problems, "DB_MAX_OPEN_CONNS, DB_MAX_IDLE_CONNS and DB_CONN_MAX_LIFETIME only apply to DB_DRIVER=postgres, SQLite always uses one connection")
+		}
+	case driverPostgres:
+		if url == "" {
+			problems = append(problems, "DB_DRIVER is postgres but DATABASE_URL is not set")
+		} else if _, err := pgx.ParseConfig(url); err != nil {
+			problems = append(problems, fmt.Sprintf("DATABASE_URL is not a valid Postgres connection string: %v", err))
+		}
+		if os.Getenv("DATABASE_PATH") != "" {
+			problems = append(problems, "DATABASE_PATH is set but DB_DRIVER is postgres, which uses DATABASE_URL")
+		}
+		if _, err := loadPoolSettings(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("DB_DRIVER %q is not supported, use sqlite or postgres", driver))
+	}
+	if path := os.Getenv("DATABASE_PATH"); path != "" {
+		if strings.TrimSpace(path) == "" {
+			problems = append(problems, "DATABASE_PATH is blank")
+		} else if info, err := os.Stat(strings.TrimSpace(path)); err == nil && info.IsDir() {
+			problems = append(problems, fmt.Sprintf("DATABASE_PATH %q is a directory, not a database file", path))
+		}
+	}
+
+	// Discord IDs, single or as lists
+	for _, name := range []string{"DEFAULT_GUILD_ID", "WORDLE_BOT_USER_ID", "MONTHLY_ANNOUNCEMENT_CHANNEL_ID", "REMINDER_CHANNEL_ID", "WINNER_ROLE_ID", "LOG_CHANNEL_ID"} {
+		if id := strings.TrimSpace(os.Getenv(name)); id != "" && !isUserID(id) {
+			problems = append(problems, fmt.Sprintf("%s must be a Discord ID, got %q", name, id))
+		}
+	}
+	for _, name := range []string{"WATCHED_CHANNEL_IDS", "ADMIN_USER_IDS", "WORDLE_WEBHOOK_IDS"} {
+		raw := os.Getenv(name)
+		if raw != "" && len(parseIDList(raw)) == 0 {
+			problems = append(problems, fmt.Sprintf("%s is set but lists no IDs", name))
+		}
+		for _, id := range slices.Sorted(maps.Keys(parseIDList(raw))) {
+			if !isUserID(id) {
+				problems = append(problems, fmt.Sprintf("%s contains %q, which is not a Discord ID", name, id))
+			}
+		}
+	}
+
+	if _, err := parseCommandAliases(os.Getenv("COMMAND_ALIASES")); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if raw := strings.TrimSpace(os.Getenv("COMMAND_COOLDOWN")); raw != "" {
+		if _, err := parseCooldown(raw); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if raw := os.Getenv("REMINDER_TIME"); raw != "" {
+		if _, _, err := parseReminderTime(raw); err != nil {
+			problems = append(problems, err.Error())
+		}
+		if strings.TrimSpace(os.Getenv("REMINDER_CHANNEL_ID")) == "" {
+			problems = append(problems, "REMINDER_TIME is set but REMINDER_CHANNEL_ID is not")
+		}
+	}
+	if addr := strings.TrimSpace(os.Getenv("METRICS_ADDR")); addr != "" && addr == strings.TrimSpace(os.Getenv("HTTP_ADDR")) {
+		problems = append(problems, fmt.Sprintf("METRICS_ADDR and HTTP_ADDR are both %q, they need their own addresses", addr))
+	}
+	if _, err := loadWordleBots(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := loadTheme(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := loadPenalties(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := loadScoring(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if tz := strings.TrimSpace(os.Getenv("TIMEZONE")); tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			problems = append(problems, fmt.Sprintf("TIMEZONE %q is not a known timezone", tz))
+		}
+	}
+	for _, t := range (&Bot{}).toggles() {
+		if raw := strings.TrimSpace(os.Getenv(t.name)); raw != "" {
+			if _, err := strconv.ParseBool(raw); err != nil {
+				problems = append(problems, fmt.Sprintf("%s must be true or false, got %q", t.name, raw))
+			}
+		}
+	}
+	return problems
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Settings read from the -config JSON file. Every key is the lower case
+// name of the environment variable it stands for (penalty_miss for
+// PENALTY_MISS, and so on) and takes the same values, with lists as JSON
+// arrays and key:value settings as JSON objects. A setting in the file wins
+// over the environment, and one in neither keeps its default:
+//
+//	{
+//	  "command_prefix": "?",
+//	  "penalty_miss": 5,
+//	  "watched_channel_ids": ["123", "456"],
+//	  "points_map": {"1": 10, "X": 0, "miss": 0},
+//	  "wooden_spoon": true
+//	}
+type Config struct {
+	DiscordBotToken string `json:"discord_bot_token"`
+	LogLevel        string `json:"log_level"`
+	DBDriver        string `json:"db_driver"`
+	DatabasePath    string `json:"database_path"`
+	DatabaseURL     string `json:"database_url"`
+	DefaultGuildID  string `json:"default_guild_id"`
+	HTTPAddr        string `json:"http_addr"`
+	MetricsAddr     string `json:"metrics_addr"`
+	Timezone        string `json:"timezone"`
+
+	DBMaxOpenConns    *int   `json:"db_max_open_conns"`
+	DBMaxIdleConns    *int   `json:"db_max_idle_conns"`
+	DBConnMaxLifetime string `json:"db_conn_max_lifetime"`
+
+	CommandPrefix   string            `json:"command_prefix"`
+	CommandAliases  map[string]string `json:"command_aliases"`
+	CommandCooldown string            `json:"command_cooldown"`
+
+	WatchedChannelIDs            []string `json:"watched_channel_ids"`
+	AdminUserIDs                 []string `json:"admin_user_ids"`
+	WordleBotUserID              string   `json:"wordle_bot_user_id"`
+	WordleBots                   []string `json:"wordle_bots"`
+	WordleWebhookIDs             []string `json:"wordle_webhook_ids"`
+	WinnerRoleID                 string   `json:"winner_role_id"`
+	MonthlyAnnouncementChannelID string   `json:"monthly_announcement_channel_id"`
+	ReminderChannelID            string   `json:"reminder_channel_id"`
+	ReminderTime                 string   `json:"reminder_time"`
+	LogChannelID                 string   `json:"log_channel_id"`
+
+	PenaltyFail         *int           `json:"penalty_fail"`
+	PenaltyMiss         *int           `json:"penalty_miss"`
+	ScoringMode         string         `json:"scoring_mode"`
+	PointsMap           map[string]int `json:"points_map"`
+	LeaderboardTiebreak string         `json:"leaderboard_tiebreak"`
+
+	LeaderboardMedals []string          `json:"leaderboard_medals"`
+	LeaderboardColor  string            `json:"leaderboard_color"`
+	StreakEmojis      map[string]string `json:"streak_emojis"`
+
+	BareNameFallback       *bool `json:"bare_name_fallback"`
+	WoodenSpoon            *bool `json:"wooden_spoon"`
+	LeaderboardEditInPlace *bool `json:"leaderboard_edit_in_place"`
+	GridCheck              *bool `json:"grid_check"`
+	NoResultsAck           *bool `json:"no_results_ack"`
+	NoResultsLeaderboard   *bool `json:"no_results_leaderboard"`
+	TrackDepartures        *bool `json:"track_departures"`
+	LeaderboardPings       *bool `json:"leaderboard_pings"`
+}
+
+// Read a config file, refusing unknown keys so a typo doesn't go unnoticed
+func loadConfigFile(path string) (Config, error) {
+	var c Config
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return c, fmt.Errorf("reading config file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, fmt.Errorf("parsing config file %s: %w", path, err)
+	}
+	return c, c.validate()
+}
+
+// Check what the file format itself can get wrong; the values are checked
+// by checkEnvironment like any other setting
+func (c Config) validate() error {
+	for _, list := range []struct {
+		key    string
+		values []string
+	}{
+		{"watched_channel_ids", c.WatchedChannelIDs},
+		{"admin_user_ids", c.AdminUserIDs},
+		{"wordle_webhook_ids", c.WordleWebhookIDs},
+		{"wordle_bots", c.WordleBots},
+		{"leaderboard_medals", c.LeaderboardMedals},
+	} {
+		if slices.ContainsFunc(list.values, func(v string) bool { return strings.TrimSpace(v) == "" }) {
+			return fmt.Errorf("config file %s contains an empty entry", list.key)
+		}
+	}
+	for alias := range c.CommandAliases {
+		if strings.TrimSpace(alias) == "" {
+			return errors.New("config file command_aliases contains an empty alias")
+		}
+	}
+	return nil
+}
+
+// The settings the file sets, environment variable name -> value in the
+// variable's format. Settings left out of the file aren't listed.
+func (c Config) environment() map[string]string {
+	env := make(map[string]string)
+	text := func(name, value string) {
+		if value != "" {
+			env[name] = value
+		}
+	}
+	list := func(name string, values []string) {
+		if values != nil {
+			env[name] = strings.Join(values, ",")
+		}
+	}
+	number := func(name string, value *int) {
+		if value != nil {
+			env[name] = strconv.Itoa(*value)
+		}
+	}
+	boolean := func(name string, value *bool) {
+		if value != nil {
+			env[name] = strconv.FormatBool(*value)
+		}
+	}
+
+	text("DISCORD_BOT_TOKEN", c.DiscordBotToken)
+	text("LOG_LEVEL", c.LogLevel)
+	text("DB_DRIVER", c.DBDriver)
+	text("DATABASE_PATH", c.DatabasePath)
+	text("DATABASE_URL", c.DatabaseURL)
+	number("DB_MAX_OPEN_CONNS", c.DBMaxOpenConns)
+	number("DB_MAX_IDLE_CONNS", c.DBMaxIdleConns)
+	text("DB_CONN_MAX_LIFETIME", c.DBConnMaxLifetime)
+	text("DEFAULT_GUILD_ID", c.DefaultGuildID)
+	text("HTTP_ADDR", c.HTTPAddr)
+	text("METRICS_ADDR", c.MetricsAddr)
+	text("TIMEZONE", c.Timezone)
+
+	text("COMMAND_PREFIX", c.CommandPrefix)
+	if c.CommandAliases != nil {
+		var pairs []string
+		for _, alias := range slices.Sorted(maps.Keys(c.CommandAliases)) {
+			pairs = append(pairs, alias+"="+c.CommandAliases[alias])
+		}
+		env["COMMAND_ALIASES"] = strings.Join(pairs, ",")
+	}
+	text("COMMAND_COOLDOWN", c.CommandCooldown)
+
+	list("WATCHED_CHANNEL_IDS", c.WatchedChannelIDs)
+	list("ADMIN_USER_IDS", c.AdminUserIDs)
+	text("WORDLE_BOT_USER_ID", c.WordleBotUserID)
+	list("WORDLE_BOTS", c.WordleBots)
+	list("WORDLE_WEBHOOK_IDS", c.WordleWebhookIDs)
+	text("WINNER_ROLE_ID", c.WinnerRoleID)
+	text("MONTHLY_ANNOUNCEMENT_CHANNEL_ID", c.MonthlyAnnouncementChannelID)
+	text("REMINDER_CHANNEL_ID", c.ReminderChannelID)
+	text("REMINDER_TIME", c.ReminderTime)
+	text("LOG_CHANNEL_ID", c.LogChannelID)
+
+	number("PENALTY_FAIL", c.PenaltyFail)
+	number("PENALTY_MISS", c.PenaltyMiss)
+	text("SCORING_MODE", c.ScoringMode)
+	if c.PointsMap != nil {
+		var pairs []string
+		for _, result := range slices.Sorted(maps.Keys(c.PointsMap)) {
+			pairs = append(pairs, result+":"+strconv.Itoa(c.PointsMap[result]))
+		}
+		env["POINTS_MAP"] = strings.Join(pairs, ",")
+	}
+	text("LEADERBOARD_TIEBREAK", c.LeaderboardTiebreak)
+
+	list("LEADERBOARD_MEDALS", c.LeaderboardMedals)
+	text("LEADERBOARD_COLOR", c.LeaderboardColor)
+	if c.StreakEmojis != nil {
+		var pairs []string
+		for _, days := range slices.Sorted(maps.Keys(c.StreakEmojis)) {
+			pairs = append(pairs, days+":"+c.StreakEmojis[days])
+		}
+		env["STREAK_EMOJIS"] = strings.Join(pairs, ",")
+	}
+
+	boolean("BARE_NAME_FALLBACK", c.BareNameFallback)
+	boolean("WOODEN_SPOON", c.WoodenSpoon)
+	boolean("LEADERBOARD_EDIT_IN_PLACE", c.LeaderboardEditInPlace)
+	boolean("GRID_CHECK", c.GridCheck)
+	boolean("NO_RESULTS_ACK", c.NoResultsAck)
+	boolean("NO_RESULTS_LEADERBOARD", c.NoResultsLeaderboard)
+	boolean("TRACK_DEPARTURES", c.TrackDepartures)
+	boolean("LEADERBOARD_PINGS", c.LeaderboardPings)
+	return env
+}
+
+// Put the file's settings in the environment, over anything already set
+// there, so the rest of the bot reads them like any other setting. Returns
+// the names of the variables the file overrode.
+func (c Config) apply() ([]string, error) {
+	var overridden []string
+	env := c.environment()
+	for _, name := range slices.Sorted(maps.Keys(env)) {
+		if _, set := os.LookupEnv(name); set {
+			overridden = append(overridden, name)
+		}
+		if err := os.Setenv(name, env[name]); err != nil {
+			return overridden, fmt.Errorf("applying %s: %w", name, err)
+		}
+	}
+	return overridden, nil
+}
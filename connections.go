@@ -0,0 +1,130 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Mistakes that end a Connections puzzle unsolved
+const connectionsMaxMistakes = 4
+
+// Matches the "Puzzle #512" line of a Connections share, capturing the number
+var connectionsPuzzleRegex = regexp.MustCompile(`(?i)^Puzzle\s*#\s*(\d{1,3}(?:,\d{3})+|\d+)$`)
+
+// The squares of a Connections grid, one colour per group
+var connectionsSquares = map[rune]bool{'🟨': true, '🟩': true, '🟦': true, '🟪': true}
+
+// Check whether a message is a player sharing their NYT Connections result:
+// a "Connections" line, a "Puzzle #N" line and a grid of coloured squares
+func (b *Bot) isConnectionsShare(msg *discordgo.Message) bool {
+	if msg.Author == nil || msg.Author.Bot {
+		return false
+	}
+	_, _, err := parseConnections(msg.Content)
+	return err == nil
+}
+
+// A share that says it is Connections but whose grid doesn't add up
+var errConnectionsGrid = errors.New("connections grid is incomplete")
+
+// Read a Connections share: the puzzle number and the mistakes made, where
+// connectionsMaxMistakes means the puzzle wasn't solved. Each grid row is
+// one guess; a row of a single colour found a group, any other row was a
+// mistake. For example
+//
+//	Connections
+//	Puzzle #512
+//	🟨🟨🟨🟨
+//	🟩🟦🟩🟩
+//	🟩🟩🟩🟩
+//	🟦🟦🟦🟦
+//	🟪🟪🟪🟪
+//
+// is puzzle 512 solved with one mistake.
+func parseConnections(content string) (puzzle, mistakes int, err error) {
+	header, found := false, 0
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.EqualFold(line, "Connections"):
+			header = true
+		case connectionsPuzzleRegex.MatchString(line):
+			puzzle, _ = strconv.Atoi(strings.ReplaceAll(connectionsPuzzleRegex.FindStringSubmatch(line)[1], ",", ""))
+		default:
+			row := []rune(line)
+			if len(row) != 4 || !connectionsSquares[row[0]] {
+				continue
+			}
+			group := true
+			for _, square := range row {
+				if !connectionsSquares[square] {
+					return 0, 0, errConnectionsGrid
+				}
+				group = group && square == row[0]
+			}
+			if group {
+				found++
+			} else {
+				mistakes++
+			}
+		}
+	}
+	if !header || puzzle == 0 {
+		return 0, 0, errors.New("not a connections share")
+	}
+	// Solved with all four groups, or stopped by the last allowed mistake
+	if found+mistakes == 0 || mistakes > connectionsMaxMistakes || (found != 4 && mistakes != connectionsMaxMistakes) {
+		return 0, 0, errConnectionsGrid
+	}
+	return puzzle, mistakes, nil
+}
+
+// Parse a Connections share into its author's result for the day
+func (b *Bot) parseConnectionsShare(s *discordgo.Session, msg *discordgo.Message) (dailyResults, error) {
+	puzzle, mistakes, err := parseConnections(msg.Content)
+	if err != nil {
+		return dailyResults{}, err
+	}
+	results := dailyResults{
+		bd:     b.boardFor(msg.GuildID, msg.ChannelID),
+		day:    resultDay{date: b.puzzleDate(msg), puzzle: puzzle},
+		scores: map[string]int{msg.Author.ID: mistakes},
+		names:  map[string]string{msg.Author.ID: cleanDisplayName(msg.Author.DisplayName())},
+		mode:   "share",
+	}
+	if b.excludedUser(msg.GuildID, msg.Author.ID) {
+		return results, errEveryoneExcluded
+	}
+	return results, nil
+}
+
+// How a Connections result is recorded: golf scoring adds the mistakes, and
+// points scoring gives 4 points for a perfect puzzle, one less per mistake
+// and none for an unsolved one
+func (b *Bot) connectionsResult(mistakes int) dayResult {
+	failed := mistakes >= connectionsMaxMistakes
+	points := connectionsMaxMistakes - mistakes
+	if failed {
+		points = 0
+	}
+	return dayResult{score: mistakes, points: points, played: true, failed: failed}
+}
+
+// "no mistakes", "1 mistake", "3 mistakes" or "not solved"
+func formatMistakes(mistakes int) string {
+	switch {
+	case mistakes == 0:
+		return "no mistakes"
+	case mistakes == 1:
+		return "1 mistake"
+	case mistakes >= connectionsMaxMistakes:
+		return "not solved"
+	default:
+		return fmt.Sprintf("%d mistakes", mistakes)
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseConnections(t *testing.T) {
+	tests := []struct {
+		name         string
+		share        string
+		wantPuzzle   int
+		wantMistakes int
+		wantErr      bool
+	}{
+		{
+			name:       "perfect",
+			share:      "Connections \nPuzzle #512\n🟨🟨🟨🟨\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪",
+			wantPuzzle: 512,
+		},
+		{
+			name:         "one mistake",
+			share:        "Connections\nPuzzle #512\n🟨🟨🟨🟨\n🟩🟦🟩🟩\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪",
+			wantPuzzle:   512,
+			wantMistakes: 1,
+		},
+		{
+			name:         "purple first, three mistakes",
+			share:        "Connections\nPuzzle #1,024\n🟪🟪🟪🟪\n🟨🟩🟨🟨\n🟦🟩🟦🟦\n🟨🟨🟨🟨\n🟩🟦🟩🟩\n🟩🟩🟩🟩\n🟦🟦🟦🟦",
+			wantPuzzle:   1024,
+			wantMistakes: 3,
+		},
+		{
+			name:         "not solved",
+			share:        "Connections\nPuzzle #513\n🟨🟨🟨🟨\n🟩🟦🟩🟩\n🟦🟪🟦🟦\n🟩🟪🟩🟩\n🟦🟩🟪🟪",
+			wantPuzzle:   513,
+			wantMistakes: connectionsMaxMistakes,
+		},
+		{
+			name:       "shared with a comment",
+			share:      "Tough one today!\nConnections\nPuzzle # 514\n🟩🟩🟩🟩\n🟨🟨🟨🟨\n🟪🟪🟪🟪\n🟦🟦🟦🟦",
+			wantPuzzle: 514,
+		},
+		{
+			name:    "grid cut short",
+			share:   "Connections\nPuzzle #515\n🟨🟨🟨🟨\n🟩🟩🟩🟩",
+			wantErr: true,
+		},
+		{
+			name:    "square from another game",
+			share:   "Connections\nPuzzle #516\n🟨🟨🟨⬛\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪",
+			wantErr: true,
+		},
+		{
+			name:    "no puzzle number",
+			share:   "Connections\n🟨🟨🟨🟨\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪",
+			wantErr: true,
+		},
+		{
+			name:    "Wordle result",
+			share:   "Wordle 1,203 3/6\n⬛🟨⬛⬛⬛\n🟩🟩🟩🟩🟩",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzle, mistakes, err := parseConnections(tt.share)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, want error %v", err, tt.wantErr)
+			}
+			if puzzle != tt.wantPuzzle || mistakes != tt.wantMistakes {
+				t.Errorf("parseConnections = puzzle %d with %d mistakes, want puzzle %d with %d", puzzle, mistakes, tt.wantPuzzle, tt.wantMistakes)
+			}
+		})
+	}
+}
+
+func TestConnectionsShareRecorded(t *testing.T) {
+	b := newTestBot(t)
+	share := func(userID, content string) *discordgo.Message {
+		return &discordgo.Message{
+			GuildID:   "guild",
+			ChannelID: "channel",
+			Author:    &discordgo.User{ID: userID, Username: "player" + userID},
+			Content:   content,
+			Timestamp: time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC),
+		}
+	}
+	record := func(msg *discordgo.Message) bool {
+		t.Helper()
+		g, ok := b.detectGame(msg)
+		if !ok || g.name != "connections" {
+			t.Fatalf("share detected as %q, %v, want connections", g.name, ok)
+		}
+		results, err := g.parse(b, nil, msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		results.bd = results.bd.forGame(g.name)
+		recorded, err := b.applyShare(g, results)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return recorded
+	}
+
+	perfect := "Connections\nPuzzle #512\n🟨🟨🟨🟨\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪"
+	twoMistakes := "Connections\nPuzzle #512\n🟨🟩🟨🟨\n🟨🟨🟨🟨\n🟩🟦🟩🟩\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪"
+	if !record(share("111", perfect)) || !record(share("222", twoMistakes)) {
+		t.Fatal("share not recorded")
+	}
+	if record(share("111", twoMistakes)) {
+		t.Error("a second share of the same puzzle was recorded")
+	}
+
+	// Scored on the Connections board, by mistakes, and nowhere else
+	bd := b.boardFor("guild", "channel")
+	want := []standing{{"111", 0, 1, 1}, {"222", 2, 1, 2}}
+	if got := standings(t, b, bd.forGame("connections"), allTimePeriod); !slices.Equal(got, want) {
+		t.Errorf("connections board = %v, want %v", got, want)
+	}
+	if wordle := standings(t, b, bd, allTimePeriod); len(wordle) != 0 {
+		t.Errorf("wordle board = %v, want it empty", wordle)
+	}
+}
+
+func TestBotPostedConnectionsIgnored(t *testing.T) {
+	b := newBot(nil)
+	msg := &discordgo.Message{
+		Author:  &discordgo.User{ID: "800", Username: "Relay", Bot: true},
+		Content: "Connections\nPuzzle #512\n🟨🟨🟨🟨\n🟩🟩🟩🟩\n🟦🟦🟦🟦\n🟪🟪🟪🟪",
+	}
+	if b.isConnectionsShare(msg) {
+		t.Error("a bot's Connections share was accepted")
+	}
+}
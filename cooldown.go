@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Default time a user must wait between two uses of the same command
+const defaultCommandCooldown = 10 * time.Second
+
+// Parse COMMAND_COOLDOWN: a duration such as "10s", or a plain number of
+// seconds. Zero turns cooldowns off.
+func parseCooldown(raw string) (time.Duration, error) {
+	text := raw
+	if seconds, err := strconv.Atoi(raw); err == nil {
+		text = strconv.Itoa(seconds) + "s"
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("COMMAND_COOLDOWN must be a duration like 10s, got %q", raw)
+	}
+	return d, nil
+}
+
+// A user's use of one command
+type cooldownKey struct {
+	userID  string
+	command string
+}
+
+// When a user last ran a command, and whether they've been told to slow down
+// since
+type cooldownEntry struct {
+	last   time.Time
+	warned bool
+}
+
+// Per-user, per-command cooldowns, kept in memory only
+type cooldowns struct {
+	mu      sync.Mutex
+	entries map[cooldownKey]*cooldownEntry
+	pruned  time.Time
+}
+
+// Check whether a user may run a command now, given the cooldown, and count
+// it as a use if so. Otherwise returns the time left; warn is true the first
+// time they are throttled in a window, so only one warning is sent.
+func (c *cooldowns) allow(userID, command string, cooldown time.Duration, now time.Time) (left time.Duration, warn bool) {
+	if cooldown <= 0 {
+		return 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[cooldownKey]*cooldownEntry)
+	}
+
+	// Drop expired entries now and then so the map doesn't keep every user
+	// who ever ran a command
+	if now.Sub(c.pruned) > cooldown {
+		for key, e := range c.entries {
+			if now.Sub(e.last) >= cooldown {
+				delete(c.entries, key)
+			}
+		}
+		c.pruned = now
+	}
+
+	key := cooldownKey{userID: userID, command: command}
+	e := c.entries[key]
+	if e == nil || now.Sub(e.last) >= cooldown {
+		c.entries[key] = &cooldownEntry{last: now}
+		return 0, false
+	}
+	warn = !e.warned
+	e.warned = true
+	return cooldown - now.Sub(e.last), warn
+}
@@ -0,0 +1,297 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Where the SQLite file lives unless DATABASE_PATH says otherwise
+const defaultDatabasePath = "./leaderboard.db"
+
+// Open the SQLite database at path, creating its directory if needed. Fails
+// up front if the file or its directory isn't writable, since SQLite would
+// otherwise only complain on the first write (and needs the directory for
+// its journal).
+func openDatabase(path string) (*sql.DB, error) {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating database directory: %w", err)
+	}
+	probe, err := os.CreateTemp(dir, ".leaderboard-write-test-*")
+	if err != nil {
+		return nil, fmt.Errorf("database directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("database file %s is not writable: %w", path, err)
+	}
+	f.Close()
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows one writer at a time, and a second connection writing
+	// while another holds a transaction fails with "database is locked"
+	// rather than waiting. Handlers run concurrently, so share a single
+	// connection: statements queue for it instead of racing each other.
+	// That also means nothing may query b.db while holding a transaction;
+	// it would wait for its own connection forever. The DB_MAX_OPEN_CONNS
+	// family of pool settings is for Postgres only for the same reason.
+	db.SetMaxOpenConns(1)
+	db.SetConnMaxLifetime(0)
+	return db, nil
+}
+
+// The SQLite backend, DB_DRIVER=sqlite or unset
+type sqliteStore struct {
+	sqlStore
+}
+
+// A store on an open SQLite database
+func newSQLiteStore(db *sql.DB) *sqliteStore {
+	return &sqliteStore{sqlStore{db: db}}
+}
+
+// The schema is written in SQLite's dialect
+func (s *sqliteStore) Schema(create string) string {
+	return create
+}
+
+// Size of the database file, from SQLite's page count
+func (s *sqliteStore) Size() (int64, error) {
+	var pages, pageSize int64
+	if err := s.db.QueryRow("PRAGMA page_count").Scan(&pages); err != nil {
+		return 0, fmt.Errorf("reading page count: %w", err)
+	}
+	if err := s.db.QueryRow("PRAGMA page_size").Scan(&pageSize); err != nil {
+		return 0, fmt.Errorf("reading page size: %w", err)
+	}
+	return pages * pageSize, nil
+}
+
+// Schema for the cumulative per-guild leaderboard
+const createLeaderboardSQL = `
+    CREATE TABLE IF NOT EXISTS leaderboard (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        guild_id TEXT NOT NULL DEFAULT '',
+        channel_id TEXT NOT NULL DEFAULT '',
+        user_id TEXT NOT NULL,
+        username TEXT NOT NULL DEFAULT '',
+        score INTEGER NOT NULL,
+		days_played INTEGER NOT NULL DEFAULT 0,
+        current_streak INTEGER NOT NULL DEFAULT 0,
+        max_streak INTEGER NOT NULL DEFAULT 0,
+        fails INTEGER NOT NULL DEFAULT 0,
+        last_rank INTEGER NOT NULL DEFAULT 0,
+        game TEXT NOT NULL DEFAULT 'wordle',
+        last_played TEXT NOT NULL DEFAULT '',
+        joined_date TEXT NOT NULL DEFAULT '',
+        active INTEGER NOT NULL DEFAULT 1
+    );`
+
+// The latest leaderboard message in each channel, edited in place when
+// LEADERBOARD_EDIT_IN_PLACE is on
+const createLeaderboardPostsSQL = `
+    CREATE TABLE IF NOT EXISTS leaderboard_posts (
+        channel_id TEXT PRIMARY KEY,
+        message_id TEXT NOT NULL
+    );`
+
+// Users who turned the daily reminder off, per guild
+const createReminderOptOutsSQL = `
+    CREATE TABLE IF NOT EXISTS reminder_optouts (
+        guild_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        PRIMARY KEY (guild_id, user_id)
+    );`
+
+// Every Wordle results message as it was received, so results can be parsed
+// again with !reprocess. mentions maps user ID to display name as JSON.
+const createRawMessagesSQL = `
+    CREATE TABLE IF NOT EXISTS raw_messages (
+        message_id TEXT PRIMARY KEY,
+        guild_id TEXT NOT NULL,
+        channel_id TEXT NOT NULL,
+        author_id TEXT NOT NULL,
+        content TEXT NOT NULL,
+        mentions TEXT NOT NULL DEFAULT '{}',
+        posted_at TIMESTAMP NOT NULL,
+        received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );`
+
+// Every player's all-time rank on a board as of the last run processed each
+// day, for !movers
+const createRankSnapshotsSQL = `
+    CREATE TABLE IF NOT EXISTS rank_snapshots (
+        guild_id TEXT NOT NULL,
+        channel_id TEXT NOT NULL DEFAULT '',
+        snapshot_date TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        rank INTEGER NOT NULL,
+        PRIMARY KEY (guild_id, channel_id, snapshot_date, user_id)
+    );`
+
+// Who holds the WINNER_ROLE_ID role in each guild, so it can be taken back
+// when someone else takes the lead
+const createWinnerRolesSQL = `
+    CREATE TABLE IF NOT EXISTS winner_roles (
+        guild_id TEXT PRIMARY KEY,
+        role_id TEXT NOT NULL,
+        user_id TEXT NOT NULL
+    );`
+
+// Days players are away, start and end inclusive, during which they get no
+// miss penalties and keep their streaks
+const createVacationsSQL = `
+    CREATE TABLE IF NOT EXISTS vacations (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        guild_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        start_date TEXT NOT NULL,
+        end_date TEXT NOT NULL,
+        set_by TEXT NOT NULL DEFAULT '',
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    );`
+
+// Users who asked to be pinged when their rank changes, per guild
+const createRankAlertsSQL = `
+    CREATE TABLE IF NOT EXISTS rank_alerts (
+        guild_id TEXT NOT NULL,
+        user_id TEXT NOT NULL,
+        PRIMARY KEY (guild_id, user_id)
+    );`
+
+// Schema for users who are never scored or penalized in a guild, either
+// excluded by an admin or opted out themselves (opted_out = 1)
+const createExcludedUsersSQL = `
+    CREATE TABLE IF NOT EXISTS excluded_users (
+        guild_id TEXT NOT NULL DEFAULT '',
+        user_id TEXT NOT NULL,
+        opted_out INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (guild_id, user_id)
+    );`
+
+// One row per user per processed day; played = 0 marks an absence penalty.
+// score holds the guesses (or the fail or miss penalty), points what the
+// result earns in points scoring.
+const createDailyResultsSQL = `
+    CREATE TABLE IF NOT EXISTS daily_results (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        guild_id TEXT NOT NULL DEFAULT '',
+        channel_id TEXT NOT NULL DEFAULT '',
+        user_id TEXT NOT NULL,
+        result_date TEXT NOT NULL,
+        score INTEGER NOT NULL,
+        played INTEGER NOT NULL DEFAULT 1,
+        failed INTEGER NOT NULL DEFAULT 0,
+        puzzle_number INTEGER NOT NULL DEFAULT 0,
+        inserted_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        points INTEGER NOT NULL DEFAULT 0,
+        game TEXT NOT NULL DEFAULT 'wordle'
+    );`
+
+// Audit trail of manual score corrections made with !adjust
+const createAdjustmentsSQL = `
+    CREATE TABLE IF NOT EXISTS adjustments (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        guild_id TEXT NOT NULL,
+        channel_id TEXT NOT NULL DEFAULT '',
+        user_id TEXT NOT NULL,
+        admin_id TEXT NOT NULL,
+        score_delta INTEGER NOT NULL,
+        days_delta INTEGER NOT NULL DEFAULT 0,
+        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        game TEXT NOT NULL DEFAULT 'wordle'
+    );`
+
+// One row per board per processed results message, used to skip replays
+const createProcessedDaysSQL = `
+    CREATE TABLE IF NOT EXISTS processed_days (
+        guild_id TEXT NOT NULL,
+        channel_id TEXT NOT NULL DEFAULT '',
+        game TEXT NOT NULL DEFAULT 'wordle',
+        puzzle_number INTEGER NOT NULL DEFAULT 0,
+        result_date TEXT NOT NULL,
+        processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        PRIMARY KEY (guild_id, channel_id, game, puzzle_number, result_date)
+    );`
+
+// One row per archived season of a board, numbered from 1
+const createSeasonsSQL = `
+    CREATE TABLE IF NOT EXISTS seasons (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        guild_id TEXT NOT NULL,
+        channel_id TEXT NOT NULL DEFAULT '',
+        number INTEGER NOT NULL,
+        name TEXT NOT NULL,
+        ended_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+        UNIQUE (guild_id, channel_id, number)
+    );`
+
+// Final leaderboard totals of each archived season
+const createSeasonStandingsSQL = `
+    CREATE TABLE IF NOT EXISTS season_standings (
+        season_id INTEGER NOT NULL REFERENCES seasons (id),
+        user_id TEXT NOT NULL,
+        username TEXT NOT NULL DEFAULT '',
+        score INTEGER NOT NULL,
+        days_played INTEGER NOT NULL,
+        max_streak INTEGER NOT NULL DEFAULT 0,
+        fails INTEGER NOT NULL DEFAULT 0,
+        PRIMARY KEY (season_id, user_id)
+    );`
+
+// Indexes on columns that older databases only gain through migrations.
+// UpdateScore's upsert relies on the first one.
+var createIndexesSQL = []string{
+	"CREATE UNIQUE INDEX IF NOT EXISTS idx_leaderboard_board_game_user ON leaderboard (guild_id, channel_id, game, user_id)",
+	"CREATE INDEX IF NOT EXISTS idx_daily_results_board_game_date ON daily_results (guild_id, channel_id, game, result_date, user_id)",
+	"CREATE INDEX IF NOT EXISTS idx_daily_results_user_inserted ON daily_results (user_id, inserted_at)",
+}
+
+// Create the database tables and bring an existing database up to date.
+// New tables are created with the current schema; the migrations then
+// upgrade tables left behind by older versions of the bot.
+func (b *Bot) initializeDatabase() error {
+	tables := []struct {
+		name   string
+		create string
+	}{
+		{"leaderboard", createLeaderboardSQL},
+		{"daily_results", createDailyResultsSQL},
+		{"excluded_users", createExcludedUsersSQL},
+		{"processed_days", createProcessedDaysSQL},
+		{"adjustments", createAdjustmentsSQL},
+		{"seasons", createSeasonsSQL},
+		{"season_standings", createSeasonStandingsSQL},
+		{"rank_alerts", createRankAlertsSQL},
+		{"leaderboard_posts", createLeaderboardPostsSQL},
+		{"reminder_optouts", createReminderOptOutsSQL},
+		{"raw_messages", createRawMessagesSQL},
+		{"rank_snapshots", createRankSnapshotsSQL},
+		{"winner_roles", createWinnerRolesSQL},
+		{"vacations", createVacationsSQL},
+	}
+	for _, t := range tables {
+		if _, err := b.db.Exec(b.store.Schema(t.create)); err != nil {
+			return fmt.Errorf("creating %s table: %w", t.name, err)
+		}
+	}
+
+	if err := b.migrate(); err != nil {
+		return err
+	}
+
+	for _, create := range createIndexesSQL {
+		if _, err := b.db.Exec(create); err != nil {
+			return fmt.Errorf("creating indexes: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Handle edited messages. The Wordle bot edits its results message as more
+// players submit, so edits are parsed again and late scores added.
+func (b *Bot) onMessageUpdate(s *discordgo.Session, m *discordgo.MessageUpdate) {
+	// Updates such as link previews can arrive without the author or content
+	if m.Author == nil || m.Author.ID == s.State.User.ID || !b.isWordleMessage(m.Message) {
+		return
+	}
+	if !b.watchesChannel(m.ChannelID) || !strings.Contains(strings.ToLower(m.Content), "results") {
+		return
+	}
+	slog.Info("Processing edited results message", "guild", m.GuildID, "channel", m.ChannelID, "message", m.ID)
+	b.processEditedResultsMessage(s, m.Message)
+}
+
+// Record an edited results message. If its day wasn't recorded yet it is
+// processed like a new message; otherwise only players missing from the
+// recorded day are added, so the scores already recorded aren't counted
+// twice.
+func (b *Bot) processEditedResultsMessage(s *discordgo.Session, msg *discordgo.Message) {
+	// Edits may leave out the original timestamp the puzzle date comes from
+	if msg.Timestamp.IsZero() {
+		if err := b.db.QueryRow("SELECT posted_at FROM raw_messages WHERE message_id = ?", msg.ID).Scan(&msg.Timestamp); err != nil && err != sql.ErrNoRows {
+			slog.Error("Error loading original message time", "err", err)
+		}
+	}
+	b.saveRawMessage(msg)
+	b.metrics.messages.Add(1)
+
+	results, err := b.parseResultsMessage(s, msg)
+	if err != nil {
+		if !errors.Is(err, errEveryoneExcluded) {
+			b.metrics.parseFailures.Add(1)
+		}
+		slog.Warn("Not recording edited results message", "guild", msg.GuildID, "channel", msg.ChannelID, "message", msg.ID, "err", err)
+		return
+	}
+
+	added, recorded, err := b.applyEditedResults(results)
+	if err != nil {
+		b.metrics.dbErrors.Add(1)
+		slog.Error("Error processing edited results", "err", err)
+		sendMessage(s, msg.ChannelID, "Something went wrong while adding late results, nothing was saved. Please let an admin know!")
+		return
+	}
+	if recorded {
+		b.announceResults(s, msg.ChannelID, results)
+		return
+	}
+	if len(added) == 0 {
+		slog.Debug("Edited results message has no new scores", "guild", msg.GuildID, "channel", msg.ChannelID, "message", msg.ID)
+		return
+	}
+
+	slog.Info("Added late results", "guild", results.bd.guildID, "channel", results.bd.channelID, "puzzle", results.day.puzzle, "date", results.day.date, "users", len(added))
+	if !b.noResultsAck {
+		sendMessage(s, msg.ChannelID, formatLateResults(results.day, added))
+	}
+	if !b.noResultsLeaderboard {
+		b.sendLeaderboard(s, msg.ChannelID, results.bd, b.postedPeriod(allTimePeriod))
+	}
+	b.announceRankChanges(s, msg.ChannelID, results.bd)
+	b.updateWinnerRole(s, results.bd)
+}
+
+// "Added late results for Wordle 1234 (2026-10-13): <@1> 3/6, <@2> X/6"
+func formatLateResults(day resultDay, added map[string]int) string {
+	userIDs := make([]string, 0, len(added))
+	for userID := range added {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	parts := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		parts[i] = fmt.Sprintf("%s %s", mention(userID), scoreResult(added[userID]))
+	}
+	return fmt.Sprintf("Added late results for %s: %s", describeDay(day), strings.Join(parts, ", "))
+}
+
+// Apply the results of an edited message. A day not yet recorded is recorded
+// in full and recorded is true. Otherwise the scores of players without a
+// played result that day are added and returned; a player who was given the
+// miss penalty for it has the penalty taken back first.
+func (b *Bot) applyEditedResults(results dailyResults) (added map[string]int, recorded bool, err error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	if !b.alreadyProcessed(results.bd, results.day) {
+		if err := b.updateScoresBasedOnResults(results.bd, results.scores, results.names, results.day); err != nil {
+			return nil, false, err
+		}
+		return nil, true, nil
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("starting late results update: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	day, previous, err := recordedDay(tx, results.bd, results.day, b.scoring.dailyColumn())
+	if err != nil {
+		return nil, false, err
+	}
+
+	added = make(map[string]int)
+	for userID, score := range results.scores {
+		prev, known := previous[userID]
+		if score == unknownScore || (known && prev.played) {
+			continue // Unknown results score nothing, played results are already in
+		}
+		if known {
+			if err := takeBackPenalty(tx, results.bd, userID, day, prev.score); err != nil {
+				return nil, false, err
+			}
+		}
+		if err := b.updateCumulativeScore(tx, results.bd, userID, results.names[userID], score, true, day); err != nil {
+			return nil, false, err
+		}
+		added[userID] = score
+	}
+	if len(added) == 0 {
+		return nil, false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("committing late results: %w", err)
+	}
+	return added, false, nil
+}
+
+// A player's recorded result for a day
+type recordedResult struct {
+	score  int  // what it added to the leaderboard total
+	played bool // false for a miss penalty
+}
+
+// The day results were recorded under, matched the way alreadyProcessed
+// matches it, and each player's recorded result for it, with the score read
+// from column
+func recordedDay(tx *sql.Tx, bd board, day resultDay, column string) (resultDay, map[string]recordedResult, error) {
+	var recorded resultDay
+	var err error
+	if day.puzzle > 0 {
+		err = tx.QueryRow("SELECT puzzle_number, result_date FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND puzzle_number = ? LIMIT 1", bd.guildID, bd.channelID, bd.game, day.puzzle).Scan(&recorded.puzzle, &recorded.date)
+	} else {
+		err = tx.QueryRow("SELECT puzzle_number, result_date FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND puzzle_number = 0 AND result_date = ?", bd.guildID, bd.channelID, bd.game, day.date).Scan(&recorded.puzzle, &recorded.date)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return day, nil, errors.New("processed day disappeared")
+	}
+	if err != nil {
+		return day, nil, fmt.Errorf("finding recorded day: %w", err)
+	}
+
+	rows, err := tx.Query("SELECT user_id, "+column+", played FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND result_date = ? AND puzzle_number = ?", bd.guildID, bd.channelID, bd.game, recorded.date, recorded.puzzle)
+	if err != nil {
+		return recorded, nil, fmt.Errorf("loading recorded results: %w", err)
+	}
+	defer rows.Close()
+
+	users := make(map[string]recordedResult)
+	for rows.Next() {
+		var userID string
+		var r recordedResult
+		if err := rows.Scan(&userID, &r.score, &r.played); err != nil {
+			return recorded, nil, fmt.Errorf("scanning recorded result: %w", err)
+		}
+		users[userID] = r
+	}
+	return recorded, users, rows.Err()
+}
+
+// Take back a miss penalty given for a day a player turns out to have
+// played: remove its row and score, and restore the streak it broke
+func takeBackPenalty(tx *sql.Tx, bd board, userID string, day resultDay, penalty int) error {
+	_, err := tx.Exec("DELETE FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND result_date = ? AND puzzle_number = ? AND played = 0", bd.guildID, bd.channelID, bd.game, userID, day.date, day.puzzle)
+	if err != nil {
+		return fmt.Errorf("removing miss penalty of %s: %w", userID, err)
+	}
+	streak, _, err := replayStreaks(tx, bd, userID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE leaderboard SET score = score - ?, current_streak = ? WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", penalty, streak, bd.guildID, bd.channelID, bd.game, userID)
+	if err != nil {
+		return fmt.Errorf("taking back miss penalty of %s: %w", userID, err)
+	}
+	return nil
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// The game results are recorded under when no other game is named, and the
+// one every result from before other games were tracked belongs to
+const defaultGame = "wordle"
+
+// A game whose results the bot records. Each game keeps its own boards:
+// results are stored under its name in the game column, so its leaderboard,
+// streaks and penalties never mix with another game's.
+type game struct {
+	name  string // stored in the game column and named after commands, e.g. "wordle"
+	title string // shown in leaderboard titles, e.g. "Wordle"
+
+	// Whether a message posts results of this game
+	detect func(b *Bot, msg *discordgo.Message) bool
+
+	// Read the day and scores from a message detect accepted. The board
+	// is set to the game's by the caller.
+	parse func(b *Bot, s *discordgo.Session, msg *discordgo.Message) (dailyResults, error)
+
+	// Set for games whose players each share their own result rather than a
+	// bot posting everyone's: a message records its author's result for the
+	// puzzle, and nobody is penalized for a day they didn't share
+	perPlayer bool
+
+	// For perPlayer games, how a parsed score is recorded and how it reads
+	result func(b *Bot, score int) dayResult
+	format func(score int) string
+}
+
+// Every game the bot can record, checked in order; a message is recorded for
+// the first game that detects it. Add new result formats here.
+var games = []game{
+	{name: defaultGame, title: "Wordle", detect: (*Bot).isWordleResults, parse: (*Bot).parseResultsMessage},
+	{name: "connections", title: "Connections", detect: (*Bot).isConnectionsShare, parse: (*Bot).parseConnectionsShare,
+		perPlayer: true, result: (*Bot).connectionsResult, format: formatMistakes},
+}
+
+// Look up a game by name, ignoring case
+func findGame(name string) (game, bool) {
+	for _, g := range games {
+		if strings.EqualFold(g.name, name) {
+			return g, true
+		}
+	}
+	return game{}, false
+}
+
+// The game a message posts results of, if any
+func (b *Bot) detectGame(msg *discordgo.Message) (game, bool) {
+	for _, g := range games {
+		if g.detect(b, msg) {
+			return g, true
+		}
+	}
+	return game{}, false
+}
+
+// Take a game name out of command arguments, returning the game (defaultGame
+// if none is named) and the other arguments
+func gameArg(args []string) (string, []string) {
+	for i, arg := range args {
+		if g, ok := findGame(arg); ok {
+			return g.name, append(args[:i:i], args[i+1:]...)
+		}
+	}
+	return defaultGame, args
+}
+
+// Title of a game for display, its name if it isn't registered
+func gameTitle(name string) string {
+	if g, ok := findGame(name); ok {
+		return g.title
+	}
+	return name
+}
+
+// Record the results of a perPlayer game's share. Unlike a Wordle summary,
+// the day stays open: each player is recorded once per puzzle, whenever they
+// share it, and there are no miss penalties. Reports whether anything was
+// recorded.
+func (b *Bot) applyShare(g game, results dailyResults) (bool, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("starting share update: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	recorded := false
+	for userID, score := range results.scores {
+		shared, err := sharedAlready(tx, results.bd, userID, results.day)
+		if err != nil {
+			return false, err
+		}
+		if shared {
+			continue
+		}
+		if err := b.addDayResult(tx, results.bd, userID, results.names[userID], g.result(b, score), results.day); err != nil {
+			return false, err
+		}
+		recorded = true
+	}
+	if !recorded {
+		return false, nil
+	}
+	if err := b.markProcessed(tx, results.bd, results.day); err != nil {
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("committing share: %w", err)
+	}
+	return true, nil
+}
+
+// Whether a player already has a played result for a puzzle, matched on the
+// date when the share had no puzzle number
+func sharedAlready(tx *sql.Tx, bd board, userID string, day resultDay) (bool, error) {
+	var exists int
+	var err error
+	if day.puzzle > 0 {
+		err = tx.QueryRow("SELECT 1 FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND puzzle_number = ? AND played = 1", bd.guildID, bd.channelID, bd.game, userID, day.puzzle).Scan(&exists)
+	} else {
+		err = tx.QueryRow("SELECT 1 FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND result_date = ? AND played = 1", bd.guildID, bd.channelID, bd.game, userID, day.date).Scan(&exists)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking earlier share of %s: %w", userID, err)
+	}
+	return true, nil
+}
+
+// "✅ Connections #512 recorded for <@1>: 1 mistake"
+func formatShare(g game, results dailyResults) string {
+	userIDs := make([]string, 0, len(results.scores))
+	for userID := range results.scores {
+		userIDs = append(userIDs, userID)
+	}
+	sort.Strings(userIDs)
+	parts := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		parts[i] = fmt.Sprintf("%s: %s", mention(userID), g.format(results.scores[userID]))
+	}
+	name := g.title
+	if results.day.puzzle > 0 {
+		name = fmt.Sprintf("%s #%d", g.title, results.day.puzzle)
+	}
+	return fmt.Sprintf("✅ %s recorded for %s", name, strings.Join(parts, ", "))
+}
+
+// Check whether a message is the Wordle bot's daily results summary
+func (b *Bot) isWordleResults(msg *discordgo.Message) bool {
+	return b.isWordleMessage(msg) && strings.Contains(strings.ToLower(msg.Content), "results")
+}
@@ -0,0 +1,127 @@
+package main
+
+import "strings"
+
+// What a grid square says about a letter
+type squareKind int
+
+const (
+	squareAbsent squareKind = iota
+	squarePresent
+	squareCorrect
+)
+
+// Grid squares in every colour scheme: standard (green/yellow) and high
+// contrast (orange/blue), with black or white for absent letters in dark
+// and light mode
+var gridSquares = map[rune]squareKind{
+	'🟩': squareCorrect,
+	'🟧': squareCorrect, // high contrast
+	'🟨': squarePresent,
+	'🟦': squarePresent, // high contrast
+	'⬛': squareAbsent,
+	'⬜': squareAbsent,
+}
+
+// Characters that may sit between grid squares without meaning anything
+func isGridFiller(r rune) bool {
+	return r == '\uFE0F' || r == ' '
+}
+
+// A posted score that disagrees with the emoji grid posted with it
+type gridMismatch struct {
+	userID  string
+	score   int // as parsed from "n/6"
+	guesses int // as counted from the grid, failScore for a grid that never solved
+}
+
+// Cross-check the "n/6" scores in a results message against the emoji grids
+// next to them. A grid is the run of square-emoji lines just before a bare
+// score line ("<@1>", grid, "3/6") or just after a score line ("3/6: <@1>",
+// grid). Grids that are cut short, or results without one, are not checked.
+func checkGrids(message string) []gridMismatch {
+	var mismatches []gridMismatch
+	var pending []string   // users mentioned on a line that had no score
+	var lastUsers []string // users of the last score line, which a grid may follow
+	lastScore := 0
+	var rows []string
+
+	// Compare the collected rows with a result and start over
+	check := func(users []string, score int) {
+		guesses, ok := gridGuesses(rows)
+		rows = nil
+		if !ok || score == unknownScore || score == guesses {
+			return
+		}
+		for _, user := range users {
+			mismatches = append(mismatches, gridMismatch{userID: cleanUsername(user), score: score, guesses: guesses})
+		}
+	}
+
+	for _, line := range strings.Split(message, "\n") {
+		if isEmojiGridLine(line) {
+			rows = append(rows, line)
+			continue
+		}
+
+		usernames := userRegex.FindAllString(line, -1)
+		scoreMatch := scoreRegex.FindStringSubmatch(line)
+		if scoreMatch == nil {
+			if len(rows) > 0 {
+				check(lastUsers, lastScore)
+			}
+			if len(usernames) > 0 {
+				pending = usernames
+				lastUsers = nil
+			}
+			continue
+		}
+
+		score := parseScore(scoreMatch[1])
+		if len(usernames) == 0 {
+			usernames = pending
+			if len(rows) > 0 {
+				check(usernames, score) // The grid came between mention and score
+			}
+		} else if len(rows) > 0 {
+			check(lastUsers, lastScore)
+		}
+		pending = nil
+		lastUsers, lastScore = usernames, score
+	}
+	if len(rows) > 0 {
+		check(lastUsers, lastScore)
+	}
+	return mismatches
+}
+
+// The guesses a grid shows: the number of rows if the last one is all
+// correct, failScore for six rows without a solve. ok is false for anything
+// else, such as a grid cut short.
+func gridGuesses(rows []string) (guesses int, ok bool) {
+	if len(rows) == 0 || len(rows) > 6 {
+		return 0, false
+	}
+	if isSolvedRow(rows[len(rows)-1]) {
+		return len(rows), true
+	}
+	if len(rows) == 6 {
+		return failScore, true
+	}
+	return 0, false
+}
+
+// Whether a grid row is five correct squares, in either colour scheme
+func isSolvedRow(row string) bool {
+	correct := 0
+	for _, r := range row {
+		if isGridFiller(r) {
+			continue
+		}
+		if gridSquares[r] != squareCorrect {
+			return false
+		}
+		correct++
+	}
+	return correct == 5
+}
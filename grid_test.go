@@ -0,0 +1,90 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestIsEmojiGridLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"🟩🟨⬛⬛⬛", true},
+		{"🟧🟦⬜⬜⬜", true}, // high contrast, light mode
+		{"🟩 🟩 🟩 🟩 🟩", true},
+		{"⬛\uFE0F⬛\uFE0F🟨⬛🟩", true},
+		{"", false},
+		{"3/6: <@111>", false},
+		{"🟩🟩🟩🟩🟩 3/6", false},
+		{"👑 🟩", false},
+	}
+	for _, tt := range tests {
+		if got := isEmojiGridLine(tt.line); got != tt.want {
+			t.Errorf("isEmojiGridLine(%q) = %v, want %v", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestIsSolvedRow(t *testing.T) {
+	tests := []struct {
+		row  string
+		want bool
+	}{
+		{"🟩🟩🟩🟩🟩", true},
+		{"🟧🟧🟧🟧🟧", true},
+		{"🟩🟧🟩🟧🟩", true}, // schemes mixed by copy and paste
+		{"🟩🟩🟩🟩🟨", false},
+		{"🟧🟧🟧🟧🟦", false},
+		{"🟩🟩🟩🟩", false},
+	}
+	for _, tt := range tests {
+		if got := isSolvedRow(tt.row); got != tt.want {
+			t.Errorf("isSolvedRow(%q) = %v, want %v", tt.row, got, tt.want)
+		}
+	}
+}
+
+func TestCheckGridsBothColourSchemes(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    []gridMismatch
+	}{
+		{
+			name:    "standard grid matching",
+			message: "<@111>\n⬛🟨⬛⬛⬛\n🟨🟩⬛⬛⬛\n🟩🟩🟩🟩🟩\n3/6",
+		},
+		{
+			name:    "high contrast grid matching",
+			message: "<@111>\n⬜🟦⬜⬜⬜\n🟦🟧⬜⬜⬜\n🟧🟧🟧🟧🟧\n3/6",
+		},
+		{
+			name:    "high contrast grid after the score",
+			message: "2/6: <@111>\n🟦🟧⬛⬛⬛\n🟧🟧🟧🟧🟧",
+		},
+		{
+			name:    "standard grid disagreeing",
+			message: "<@111>\n🟨🟩⬛⬛⬛\n🟩🟩🟩🟩🟩\n4/6",
+			want:    []gridMismatch{{userID: "111", score: 4, guesses: 2}},
+		},
+		{
+			name:    "high contrast grid disagreeing",
+			message: "<@111>\n🟦🟧⬜⬜⬜\n🟧🟧🟧🟧🟧\n4/6",
+			want:    []gridMismatch{{userID: "111", score: 4, guesses: 2}},
+		},
+		{
+			name: "high contrast fail",
+			message: "X/6: <@111>\n" +
+				"🟦⬜⬜⬜⬜\n🟦⬜⬜⬜⬜\n🟦⬜⬜⬜⬜\n" +
+				"🟦⬜⬜⬜⬜\n🟦⬜⬜⬜⬜\n🟧🟧🟧🟧🟦",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checkGrids(tt.message); !slices.Equal(got, tt.want) {
+				t.Errorf("checkGrids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
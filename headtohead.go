@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Reorder runs of players with the same average and days played (and last
+// played date, with LEADERBOARD_TIEBREAK=recent) by their head-to-head
+// record: on the days both played, whoever had the lower score won that
+// day. Within a run each player's net wins against the others
+// decide, so for two players whoever beat the other more often goes first.
+// Players still level keep the user ID order from the query. Only the order
+// changes; tied players keep sharing a rank.
+func (b *Bot) breakTiesHeadToHead(bd board, period leaderboardPeriod, entries []leaderboardEntry) error {
+	for start := 0; start < len(entries); {
+		end := start + 1
+		for end < len(entries) && b.levelAfterTiebreak(entries[end], entries[start]) {
+			end++
+		}
+		if end-start > 1 {
+			if err := b.sortByHeadToHead(bd, period, entries[start:end]); err != nil {
+				return err
+			}
+		}
+		start = end
+	}
+	return nil
+}
+
+// Whether two entries are still level after the query's tiebreaks, so that
+// only head-to-head records can split them
+func (b *Bot) levelAfterTiebreak(a, o leaderboardEntry) bool {
+	if !a.tiedWith(o) || a.daysPlayed != o.daysPlayed {
+		return false
+	}
+	return !b.scoring.breaksTiesByRecency() || a.lastPlayed == o.lastPlayed
+}
+
+// Sort tied players by net head-to-head wins against each other
+func (b *Bot) sortByHeadToHead(bd board, period leaderboardPeriod, tied []leaderboardEntry) error {
+	userIDs := make([]any, len(tied))
+	for i, e := range tied {
+		userIDs[i] = e.userID
+	}
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(tied)), ", ")
+
+	// Days each player beat each other, within the leaderboard's period
+	args := []any{bd.guildID, bd.channelID, bd.game, period.since, period.since, period.until, period.until}
+	args = append(append(args, userIDs...), userIDs...)
+	rows, err := b.db.Query(`
+    SELECT a.user_id, SUM(CASE WHEN a.score < o.score THEN 1 WHEN a.score > o.score THEN -1 ELSE 0 END)
+    FROM daily_results a
+    JOIN daily_results o ON o.guild_id = a.guild_id AND o.channel_id = a.channel_id AND o.game = a.game
+      AND o.result_date = a.result_date AND o.user_id <> a.user_id AND o.played = 1
+    WHERE a.guild_id = ? AND a.channel_id = ? AND a.game = ? AND a.played = 1
+      AND (? = '' OR a.result_date >= ?) AND (? = '' OR a.result_date < ?)
+      AND a.user_id IN (`+in+`) AND o.user_id IN (`+in+`)
+    GROUP BY a.user_id`, args...)
+	if err != nil {
+		return fmt.Errorf("loading head-to-head records: %w", err)
+	}
+	defer rows.Close()
+
+	net := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var wins int
+		if err := rows.Scan(&userID, &wins); err != nil {
+			return fmt.Errorf("scanning head-to-head record: %w", err)
+		}
+		net[userID] = wins
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	sort.SliceStable(tied, func(i, j int) bool {
+		return net[tied[i].userID] > net[tied[j].userID]
+	})
+	return nil
+}
+
+// Two players' record on the days they both played
+type headToHeadRecord struct {
+	days         int // days both played
+	wins, losses int // days the first player scored lower, and higher
+}
+
+// Head-to-head record of userID against otherID on a board, all time
+func (b *Bot) headToHead(bd board, userID, otherID string) (headToHeadRecord, error) {
+	var r headToHeadRecord
+	err := b.db.QueryRow(`
+    SELECT COUNT(*), COALESCE(SUM(CASE WHEN a.score < o.score THEN 1 ELSE 0 END), 0), COALESCE(SUM(CASE WHEN a.score > o.score THEN 1 ELSE 0 END), 0)
+    FROM daily_results a
+    JOIN daily_results o ON o.guild_id = a.guild_id AND o.channel_id = a.channel_id AND o.game = a.game
+      AND o.result_date = a.result_date AND o.played = 1
+    WHERE a.guild_id = ? AND a.channel_id = ? AND a.game = ? AND a.played = 1
+      AND a.user_id = ? AND o.user_id = ?`, bd.guildID, bd.channelID, bd.game, userID, otherID).Scan(&r.days, &r.wins, &r.losses)
+	if err != nil {
+		return r, fmt.Errorf("loading head-to-head record: %w", err)
+	}
+	return r, nil
+}
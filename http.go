@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// JSON form of one ranked player
+type standingJSON struct {
+	Rank       int     `json:"rank"`
+	UserID     string  `json:"user_id"`
+	Username   string  `json:"username"`
+	TotalScore int     `json:"total_score"`
+	DaysPlayed int     `json:"days_played"`
+	Average    float64 `json:"average"`
+}
+
+// JSON body of GET /leaderboard
+type leaderboardJSON struct {
+	Guild     string         `json:"guild"`
+	Channel   string         `json:"channel,omitempty"`
+	Game      string         `json:"game"`
+	Period    string         `json:"period"`
+	Standings []standingJSON `json:"standings"`
+}
+
+// HTTP server publishing the leaderboard, started when HTTP_ADDR is set
+func (b *Bot) newHTTPServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /leaderboard", b.handleLeaderboardJSON)
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+	}
+}
+
+// Serve GET /leaderboard?guild=ID[&channel=ID][&game=NAME][&period=week|month]
+func (b *Bot) handleLeaderboardJSON(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	guildID := query.Get("guild")
+	if guildID == "" {
+		http.Error(w, "missing guild parameter", http.StatusBadRequest)
+		return
+	}
+	bd := b.boardFor(guildID, query.Get("channel"))
+	if name := query.Get("game"); name != "" {
+		g, ok := findGame(name)
+		if !ok {
+			http.Error(w, "unknown game", http.StatusBadRequest)
+			return
+		}
+		bd = bd.forGame(g.name)
+	}
+	period := parsePeriod(query.Get("period"), b.now())
+
+	entries, err := b.leaderboardEntries(bd, period)
+	if err != nil {
+		slog.Error("Error fetching leaderboard for HTTP", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	body := leaderboardJSON{
+		Guild:     bd.guildID,
+		Channel:   bd.channelID,
+		Game:      bd.game,
+		Period:    periodRange(period),
+		Standings: make([]standingJSON, len(entries)),
+	}
+	for i, rank := range competitionRanks(entries) {
+		e := entries[i]
+		body.Standings[i] = standingJSON{
+			Rank:       rank,
+			UserID:     e.userID,
+			Username:   e.username,
+			TotalScore: e.totalScore,
+			DaysPlayed: e.daysPlayed,
+			Average:    e.average(),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		slog.Error("Error writing leaderboard JSON", "err", err)
+	}
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// One historical result read from an import file
+type importRow struct {
+	date   string
+	userID string
+	score  int // 1-6, or failScore for X/6
+}
+
+// Backfill past results from a CSV or JSON file (chosen by extension) into a
+// board. Each date goes through updateScoresBasedOnResults like a live
+// results message, in date order, and dates the board already has results
+// for are skipped so re-running an import is harmless. Returns the number of
+// rows imported and the number of dates skipped.
+func (b *Bot) importResults(bd board, path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	var rows []importRow
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rows, err = readImportCSV(f)
+	case ".json":
+		rows, err = readImportJSON(f)
+	default:
+		return 0, 0, fmt.Errorf("unsupported import file %q, expected .csv or .json", path)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Group by day so each date is scored as one results message
+	byDate := make(map[string]map[string]int)
+	for _, r := range rows {
+		if byDate[r.date] == nil {
+			byDate[r.date] = make(map[string]int)
+		}
+		byDate[r.date][r.userID] = r.score
+	}
+	dates := make([]string, 0, len(byDate))
+	for date := range byDate {
+		dates = append(dates, date)
+	}
+	slices.Sort(dates)
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	imported, skipped := 0, 0
+	for _, date := range dates {
+		done, err := b.hasResultsOn(bd, date)
+		if err != nil {
+			return imported, skipped, err
+		}
+		if done {
+			slog.Info("Skipping imported date that already has results", "date", date)
+			skipped++
+			continue
+		}
+		if err := b.updateScoresBasedOnResults(bd, byDate[date], nil, resultDay{date: date}); err != nil {
+			return imported, skipped, fmt.Errorf("importing %s: %w", date, err)
+		}
+		imported += len(byDate[date])
+	}
+	return imported, skipped, nil
+}
+
+// Check whether a board has processed any results for a date, with or
+// without a puzzle number
+func (b *Bot) hasResultsOn(bd board, date string) (bool, error) {
+	var count int
+	err := b.db.QueryRow("SELECT COUNT(*) FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND result_date = ?", bd.guildID, bd.channelID, bd.game, date).Scan(&count)
+	return count > 0, err
+}
+
+// Read "date,user,score" rows; a header row is required
+func readImportCSV(r io.Reader) ([]importRow, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = 3
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading CSV header: %w", err)
+	}
+	if !slices.Equal(header, []string{"date", "user", "score"}) {
+		return nil, fmt.Errorf("CSV header must be date,user,score, got %s", strings.Join(header, ","))
+	}
+
+	var rows []importRow
+	for line := 2; ; line++ {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		row, err := newImportRow(record[0], record[1], record[2])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Read a JSON array of {"date": "...", "user": "...", "score": 3 or "X"}
+func readImportJSON(r io.Reader) ([]importRow, error) {
+	var records []struct {
+		Date  string          `json:"date"`
+		User  string          `json:"user"`
+		Score json.RawMessage `json:"score"`
+	}
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, fmt.Errorf("decoding JSON: %w", err)
+	}
+
+	rows := make([]importRow, 0, len(records))
+	for i, rec := range records {
+		score := strings.Trim(string(rec.Score), `"`)
+		row, err := newImportRow(rec.Date, rec.User, score)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i+1, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// Validate one imported result. Scores may be written "3", "3/6", "X" or "X/6",
+// and users as an ID or a <@ID> mention.
+func newImportRow(date, user, score string) (importRow, error) {
+	if _, err := time.Parse(dateLayout, date); err != nil {
+		return importRow{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+	}
+	userID := cleanUsername(strings.TrimSpace(user))
+	if userID == "" {
+		return importRow{}, errors.New("missing user")
+	}
+
+	guesses := strings.ToUpper(strings.TrimSuffix(strings.TrimSpace(score), "/6"))
+	if guesses == "X" {
+		return importRow{date: date, userID: userID, score: failScore}, nil
+	}
+	n, err := strconv.Atoi(guesses)
+	if err != nil || n < 1 || n > 6 {
+		return importRow{}, fmt.Errorf("invalid score %q, expected 1-6 or X", score)
+	}
+	return importRow{date: date, userID: userID, score: n}, nil
+}
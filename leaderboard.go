@@ -0,0 +1,627 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// leaderboardPeriod describes which days a leaderboard covers and how it
+// ranks the players on them
+type leaderboardPeriod struct {
+	label string // shown in the leaderboard header, empty for all time
+	since string // first result_date included, empty for all time
+	until string // first result_date excluded, empty for no upper bound
+	sort  leaderboardSort
+	plain bool // players by display name instead of mention, so nobody is pinged
+}
+
+// What a leaderboard ranks players by
+type leaderboardSort int
+
+const (
+	sortAverage leaderboardSort = iota // lowest average score first
+	sortTotal                          // lowest total points first
+	sortWins                           // most days solved first
+	sortStreak                         // longest current streak first
+)
+
+// Values accepted by "sort=" on the leaderboard command
+var leaderboardSorts = map[string]leaderboardSort{
+	"average": sortAverage,
+	"total":   sortTotal,
+	"wins":    sortWins,
+	"streak":  sortStreak,
+}
+
+// Map a sort key to its mode, falling back to average for anything unknown
+func parseSort(arg string) leaderboardSort {
+	if by, ok := leaderboardSorts[strings.ToLower(arg)]; ok {
+		return by
+	}
+	return sortAverage
+}
+
+// Average in hundredths, rounded half up the same way as
+// leaderboardEntry.averageCents, so the query ranks by the average shown
+const averageCentsSQL = "((total * 200 + days) / (days * 2))"
+
+// The first (aggregate MIN) or latest (MAX) date a leaderboard row's player
+// played, from daily_results, for rebuilding leaderboard.joined_date and
+// last_played
+func playedDateSQL(aggregate string) string {
+	return `COALESCE((SELECT ` + aggregate + `(d.result_date) FROM daily_results d
+    WHERE d.guild_id = leaderboard.guild_id AND d.channel_id = leaderboard.channel_id AND d.game = leaderboard.game
+      AND d.user_id = leaderboard.user_id AND d.played = 1), '')`
+}
+
+// ORDER BY clause for each sort mode, over the columns TopPlayers
+// selects. Ties on the metric fall back to something meaningful for the
+// mode, then to the user ID so the order is stable. Totals and averages rank
+// lowest first in golf scoring and highest first in points scoring; with
+// LEADERBOARD_TIEBREAK=recent equal averages put the latest player first.
+func (sc scoring) leaderboardOrder(by leaderboardSort) string {
+	best := sc.bestFirst()
+	switch by {
+	case sortTotal:
+		return "total " + best + ", days DESC, player ASC"
+	case sortWins:
+		return "wins DESC, days ASC, player ASC"
+	case sortStreak:
+		return "streak DESC, best_streak DESC, " + averageCentsSQL + " " + best + ", player ASC"
+	default:
+		if sc.breaksTiesByRecency() {
+			return averageCentsSQL + " " + best + ", last_played DESC, days DESC, player ASC"
+		}
+		return averageCentsSQL + " " + best + ", days DESC, player ASC"
+	}
+}
+
+// Metric named in the leaderboard title
+func (by leaderboardSort) label() string {
+	switch by {
+	case sortTotal:
+		return "Total Points"
+	case sortWins:
+		return "Wins"
+	case sortStreak:
+		return "Current Streak"
+	default:
+		return "Average Score"
+	}
+}
+
+// Whether two entries share a rank under this sort mode
+func (by leaderboardSort) tied(a, b leaderboardEntry) bool {
+	switch by {
+	case sortTotal:
+		return a.totalScore == b.totalScore
+	case sortWins:
+		return a.wins == b.wins
+	case sortStreak:
+		return a.streak == b.streak
+	default:
+		return a.tiedWith(b)
+	}
+}
+
+// An entry's value of the ranked metric, as shown on its standings line
+func (by leaderboardSort) metric(e leaderboardEntry) string {
+	switch by {
+	case sortTotal:
+		return fmt.Sprintf("%d pts", e.totalScore)
+	case sortWins:
+		return fmt.Sprintf("%d wins", e.wins)
+	case sortStreak:
+		return fmt.Sprintf("%d days", e.streak)
+	default:
+		// Days played is the visible tiebreak between equal averages
+		return fmt.Sprintf("%s (%d days)", e.shownAverage(), e.daysPlayed)
+	}
+}
+
+// All-time leaderboard (cumulative totals)
+var allTimePeriod = leaderboardPeriod{}
+
+// Leaderboard covering the day containing now and the previous 6 days
+func weeklyPeriod(now time.Time) leaderboardPeriod {
+	return leaderboardPeriod{
+		label: "Last 7 Days",
+		since: now.AddDate(0, 0, -6).Format(dateLayout),
+		until: now.AddDate(0, 0, 1).Format(dateLayout),
+	}
+}
+
+// Leaderboard covering the calendar month containing t, in t's timezone
+func monthlyPeriod(t time.Time) leaderboardPeriod {
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	return leaderboardPeriod{
+		label: start.Format("January 2006"),
+		since: start.Format(dateLayout),
+		until: start.AddDate(0, 1, 0).Format(dateLayout),
+	}
+}
+
+// Standings as they were at the end of date (YYYY-MM-DD), summed from the
+// per-day results recorded through it. Dates after today are refused.
+func asOfPeriod(date string, now time.Time) (leaderboardPeriod, error) {
+	day, err := time.ParseInLocation(dateLayout, date, now.Location())
+	if err != nil {
+		return leaderboardPeriod{}, fmt.Errorf("%q isn't a date, use YYYY-MM-DD like 2024-03-01", date)
+	}
+	if day.Format(dateLayout) > now.Format(dateLayout) {
+		return leaderboardPeriod{}, fmt.Errorf("%s is in the future, pick today or an earlier date", date)
+	}
+	return leaderboardPeriod{
+		label: "as of " + day.Format("Jan 2, 2006"),
+		until: day.AddDate(0, 0, 1).Format(dateLayout),
+	}, nil
+}
+
+// Whether a period is the standings as of a past date, which start at
+// the beginning of history (or of the season) rather than at since
+func (p leaderboardPeriod) asOf() bool {
+	return p.since == "" && p.until != ""
+}
+
+// Map a leaderboard argument ("week", "month") to its period relative to now,
+// defaulting to all time
+func parsePeriod(arg string, now time.Time) leaderboardPeriod {
+	switch strings.ToLower(arg) {
+	case "week":
+		return weeklyPeriod(now)
+	case "month":
+		return monthlyPeriod(now)
+	default:
+		return allTimePeriod
+	}
+}
+
+// Discord embed limits: characters per field value, and fields per embed
+// (kept low enough to stay under the 6000 character total per message)
+const (
+	embedFieldLimit     = 1024
+	embedFieldsPerEmbed = 5
+)
+
+// Players shown on each page of "!leaderboard page N"
+const leaderboardPageSize = 20
+
+// Parse "!leaderboard" arguments: an optional period ("week", "month" or
+// "on YYYY-MM-DD"), an optional "page N" and an optional "sort=KEY". A page
+// of 0 means the whole leaderboard. The error, for a bad date, is meant for
+// the user.
+func parseLeaderboardArgs(args []string, now time.Time) (leaderboardPeriod, int, error) {
+	period := allTimePeriod
+	by := sortAverage
+	page := 0
+	for i := 0; i < len(args); i++ {
+		if key, ok := strings.CutPrefix(strings.ToLower(args[i]), "sort="); ok {
+			by = parseSort(key)
+			continue
+		}
+		if strings.EqualFold(args[i], "on") {
+			if i+1 >= len(args) {
+				return period, page, errors.New("Give a date after on, e.g. on 2024-03-01")
+			}
+			var err error
+			period, err = asOfPeriod(args[i+1], now)
+			if err != nil {
+				return period, page, err
+			}
+			i++
+			continue
+		}
+		if strings.EqualFold(args[i], "page") && i+1 < len(args) {
+			if n, err := strconv.Atoi(args[i+1]); err == nil {
+				page = n
+			}
+			i++
+			continue
+		}
+		period = parsePeriod(args[i], now)
+	}
+	period.sort = by
+	return period, page, nil
+}
+
+// Fetch and send the leaderboard. One that doesn't fit on a single page is
+// posted as page 1 with reactions to page through it.
+func (b *Bot) sendLeaderboard(s *discordgo.Session, channelID string, bd board, period leaderboardPeriod) {
+	lines, err := b.leaderboardLines(bd, period)
+	if err != nil {
+		slog.Error("Error fetching leaderboard", "err", err)
+		return
+	}
+	if len(lines) > leaderboardPageSize {
+		b.sendPagedLeaderboard(s, channelID, bd, period)
+		return
+	}
+
+	embeds, err := b.buildLeaderboard(bd, period)
+	if err != nil {
+		slog.Error("Error fetching leaderboard", "err", err)
+		return
+	}
+	if len(embeds) == 1 {
+		b.postLeaderboard(s, channelID, bd, embeds[0])
+		return
+	}
+
+	// Send the embeds to the Discord channel. A leaderboard spread over
+	// several messages can't be edited in place, so a later one starts fresh.
+	if bd.game == defaultGame {
+		b.forgetLeaderboardPost(channelID)
+	}
+	for _, embed := range embeds {
+		if err := sendEmbed(s, channelID, embed); err != nil {
+			return
+		}
+	}
+}
+
+// Post a leaderboard that fits in one message. With LEADERBOARD_EDIT_IN_PLACE
+// the channel's previous leaderboard post is edited instead, as long as it
+// still exists; only Wordle leaderboards are, so other games' don't replace
+// it. Returns the message, or nil if it couldn't be sent.
+func (b *Bot) postLeaderboard(s *discordgo.Session, channelID string, bd board, embed *discordgo.MessageEmbed) *discordgo.Message {
+	editInPlace := b.editLeaderboards && bd.game == defaultGame
+	if editInPlace {
+		var messageID string
+		err := b.db.QueryRow("SELECT message_id FROM leaderboard_posts WHERE channel_id = ?", channelID).Scan(&messageID)
+		if err != nil && err != sql.ErrNoRows {
+			slog.Error("Error looking up last leaderboard post", "err", err)
+		}
+		if err == nil {
+			b.pagers.remove(messageID) // Paged again by the caller if need be
+			if msg, err := editEmbedMessage(s, channelID, messageID, embed); err == nil {
+				return msg
+			}
+			slog.Info("Last leaderboard post can't be edited, posting a new one", "channel", channelID, "message", messageID)
+		}
+	}
+
+	msg, err := sendEmbedMessage(s, channelID, embed)
+	if err != nil {
+		return nil
+	}
+	if editInPlace {
+		b.saveLeaderboardPost(channelID, msg.ID)
+	}
+	return msg
+}
+
+// Remember a channel's latest leaderboard post for editing in place
+func (b *Bot) saveLeaderboardPost(channelID, messageID string) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	_, err := b.db.Exec("INSERT INTO leaderboard_posts (channel_id, message_id) VALUES (?, ?) ON CONFLICT (channel_id) DO UPDATE SET message_id = excluded.message_id", channelID, messageID)
+	if err != nil {
+		slog.Error("Error saving leaderboard post", "err", err)
+	}
+}
+
+// Stop editing a channel's last leaderboard post
+func (b *Bot) forgetLeaderboardPost(channelID string) {
+	if !b.editLeaderboards {
+		return
+	}
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	if _, err := b.db.Exec("DELETE FROM leaderboard_posts WHERE channel_id = ?", channelID); err != nil {
+		slog.Error("Error forgetting leaderboard post", "err", err)
+	}
+}
+
+// Title shown at the top of a game's leaderboard
+func leaderboardTitle(game string, period leaderboardPeriod) string {
+	if period.label == "" {
+		return fmt.Sprintf("📊 %s Leaderboard (%s)", gameTitle(game), period.sort.label())
+	}
+	return fmt.Sprintf("📊 %s Leaderboard (%s, %s)", gameTitle(game), period.sort.label(), period.label)
+}
+
+// Human readable range of days a period covers, e.g. "Oct 1 – Oct 31, 2026"
+func periodRange(period leaderboardPeriod) string {
+	if period.asOf() {
+		if until, err := time.Parse(dateLayout, period.until); err == nil {
+			return "Through " + until.AddDate(0, 0, -1).Format("Jan 2, 2006")
+		}
+	}
+	if period.since == "" {
+		return "All time"
+	}
+	since, err := time.Parse(dateLayout, period.since)
+	if err != nil {
+		return period.since
+	}
+	last := time.Now()
+	if period.until != "" {
+		if until, err := time.Parse(dateLayout, period.until); err == nil {
+			last = until.AddDate(0, 0, -1)
+		}
+	}
+	return fmt.Sprintf("%s – %s", since.Format("Jan 2"), last.Format("Jan 2, 2006"))
+}
+
+// Empty leaderboard embed with the title, colour, timestamp and footer filled in
+func (b *Bot) newLeaderboardEmbed(bd board, period leaderboardPeriod, footer string) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:     leaderboardTitle(bd.game, period),
+		Color:     b.theme.color,
+		Timestamp: time.Now().Format(time.RFC3339),
+		Footer:    &discordgo.MessageEmbedFooter{Text: footer},
+	}
+}
+
+// Footer text: how many players are ranked and which days are covered
+func leaderboardFooter(players int, period leaderboardPeriod) string {
+	noun := "players"
+	if players == 1 {
+		noun = "player"
+	}
+	return fmt.Sprintf("%d %s ranked • %s", players, noun, periodRange(period))
+}
+
+// Build the full leaderboard for a period as one or more embeds, each sent
+// as its own message
+func (b *Bot) buildLeaderboard(bd board, period leaderboardPeriod) ([]*discordgo.MessageEmbed, error) {
+	lines, err := b.leaderboardLines(bd, period)
+	if err != nil {
+		return nil, err
+	}
+
+	footer := leaderboardFooter(len(lines), period)
+
+	// If no rows are found, say so
+	if len(lines) == 0 {
+		embed := b.newLeaderboardEmbed(bd, period, footer)
+		embed.Description = "No results available yet!"
+		return []*discordgo.MessageEmbed{embed}, nil
+	}
+
+	return standingsEmbeds(lines, func() *discordgo.MessageEmbed {
+		return b.newLeaderboardEmbed(bd, period, footer)
+	}), nil
+}
+
+// Spread standings lines over as many embeds as the field limits need, each
+// started from newEmbed and titled "(continued)" after the first
+func standingsEmbeds(lines []string, newEmbed func() *discordgo.MessageEmbed) []*discordgo.MessageEmbed {
+	fields := standingsFields(lines)
+	var embeds []*discordgo.MessageEmbed
+	for start := 0; start < len(fields); start += embedFieldsPerEmbed {
+		embed := newEmbed()
+		if start > 0 {
+			embed.Title += " (continued)"
+		}
+		embed.Fields = fields[start:min(start+embedFieldsPerEmbed, len(fields))]
+		embeds = append(embeds, embed)
+	}
+	return embeds
+}
+
+// Build a single page of the leaderboard (pages start at 1)
+func (b *Bot) buildLeaderboardPage(bd board, period leaderboardPeriod, page int) (*discordgo.MessageEmbed, error) {
+	lines, err := b.leaderboardLines(bd, period)
+	if err != nil {
+		return nil, err
+	}
+
+	embed := b.newLeaderboardEmbed(bd, period, leaderboardFooter(len(lines), period))
+	if len(lines) == 0 {
+		embed.Description = "No results available yet!"
+		return embed, nil
+	}
+
+	pages := (len(lines) + leaderboardPageSize - 1) / leaderboardPageSize
+	if page < 1 || page > pages {
+		embed.Description = fmt.Sprintf("Page %d doesn't exist, the leaderboard has %d page(s).", page, pages)
+		return embed, nil
+	}
+	start := (page - 1) * leaderboardPageSize
+	end := min(start+leaderboardPageSize, len(lines))
+
+	embed.Footer.Text = fmt.Sprintf("Page %d of %d • %s", page, pages, embed.Footer.Text)
+	embed.Fields = standingsFields(lines[start:end])
+	return embed, nil
+}
+
+// Pack standings lines into embed fields no longer than embedFieldLimit
+func standingsFields(lines []string) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+	current := ""
+	flush := func() {
+		name := "Standings"
+		if len(fields) > 0 {
+			name = "\u200b" // Discord requires a non-empty field name
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: name, Value: current})
+		current = ""
+	}
+	for _, line := range lines {
+		if len(current)+len(line) > embedFieldLimit {
+			flush()
+		}
+		current += line
+	}
+	if current != "" {
+		flush()
+	}
+	return fields
+}
+
+// One player's totals on a leaderboard
+type leaderboardEntry struct {
+	userID     string
+	username   string // display name cache, may be empty
+	totalScore int
+	daysPlayed int
+	wins       int    // days solved (1-6, not X) according to daily_results
+	streak     int    // current streak, all time even on windowed leaderboards
+	lastPlayed string // result_date of the latest played day, all time
+}
+
+// Average score per day played, 0 for an entry without any
+func (e leaderboardEntry) average() float64 {
+	if e.daysPlayed <= 0 {
+		return 0
+	}
+	return float64(e.totalScore) / float64(e.daysPlayed)
+}
+
+// Average score per day played in hundredths, rounded half up. Ranking uses
+// this rather than the exact average, so players shown with the same
+// average to two decimals are always tied.
+func (e leaderboardEntry) averageCents() int {
+	if e.daysPlayed <= 0 {
+		return 0
+	}
+	return (e.totalScore*200 + e.daysPlayed) / (e.daysPlayed * 2)
+}
+
+// The average as displayed, to the two decimals it is ranked by
+func (e leaderboardEntry) shownAverage() string {
+	return fmt.Sprintf("%.2f", float64(e.averageCents())/100)
+}
+
+// Whether two entries have the same average to two decimals, compared
+// without floating point so that e.g. 7/3 and 14/6 always tie
+func (e leaderboardEntry) tiedWith(other leaderboardEntry) bool {
+	return e.averageCents() == other.averageCents()
+}
+
+// Standard competition ranking ("1224") for entries sorted best average
+// first: tied players share a rank and the next distinct average skips ahead
+func competitionRanks(entries []leaderboardEntry) []int {
+	return sortAverage.ranks(entries)
+}
+
+// Competition ranking for entries sorted best first under this sort mode
+func (by leaderboardSort) ranks(entries []leaderboardEntry) []int {
+	ranks := make([]int, len(entries))
+	for i := range entries {
+		if i > 0 && by.tied(entries[i], entries[i-1]) {
+			ranks[i] = ranks[i-1]
+		} else {
+			ranks[i] = i + 1
+		}
+	}
+	return ranks
+}
+
+// Load the players on the leaderboard for a period, best first under its sort mode
+func (b *Bot) leaderboardEntries(bd board, period leaderboardPeriod) ([]leaderboardEntry, error) {
+	entries, err := b.store.TopPlayers(bd, period, b.scoring)
+	if err != nil {
+		return nil, err
+	}
+
+	if period.sort == sortAverage {
+		if err := b.breakTiesHeadToHead(bd, period, entries); err != nil {
+			return nil, err
+		}
+	}
+	return entries, nil
+}
+
+// Build one ranked line per player on the leaderboard for a period
+func (b *Bot) leaderboardLines(bd board, period leaderboardPeriod) ([]string, error) {
+	entries, err := b.leaderboardEntries(bd, period)
+	if err != nil {
+		return nil, err
+	}
+	return b.standingsLines(entries, period.sort, period.plain), nil
+}
+
+// A leaderboard the bot posts by itself, after results or at the end of a
+// month: players are shown by name unless LEADERBOARD_PINGS is on
+func (b *Bot) postedPeriod(period leaderboardPeriod) leaderboardPeriod {
+	period.plain = !b.leaderboardPings
+	return period
+}
+
+// One ranked line per entry, for entries sorted best first under by, with
+// the theme's medals and streak emojis. Players keyed by a name rather than
+// a user ID can't be mentioned and always show by name; plain does the same
+// for everyone with a cached display name.
+func (b *Bot) standingsLines(entries []leaderboardEntry, by leaderboardSort, plain bool) []string {
+	lines := make([]string, len(entries))
+	for i, rank := range by.ranks(entries) {
+		e := entries[i]
+		player := mention(e.userID)
+		if e.username != "" && (plain || !isUserID(e.userID)) {
+			player = "**" + escapeMarkdown(e.username) + "**"
+		}
+		if emoji := b.theme.streakEmoji(e.streak); emoji != "" {
+			player += " " + emoji
+		}
+		lines[i] = fmt.Sprintf("%s %s - %s\n", b.theme.medal(rank), player, by.metric(e))
+	}
+	return lines
+}
+
+// Build the list of top current streaks
+func (b *Bot) buildStreaks(bd board) (string, error) {
+	rows, err := b.db.Query(`
+    SELECT user_id, current_streak, max_streak
+    FROM leaderboard
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND current_streak > 0 AND active = 1
+      AND user_id NOT IN (SELECT user_id FROM excluded_users WHERE guild_id = ?)
+    ORDER BY current_streak DESC, max_streak DESC, user_id ASC
+    LIMIT 10`, bd.guildID, bd.channelID, bd.game, bd.guildID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	output := fmt.Sprintf("🔥 **Current %s Streaks** 🔥\n", gameTitle(bd.game))
+	position := 0
+	for rows.Next() {
+		var userID string
+		var currentStreak, maxStreak int
+		if err := rows.Scan(&userID, &currentStreak, &maxStreak); err != nil {
+			slog.Error("Error scanning streak row", "err", err)
+			continue
+		}
+		position++
+		output += fmt.Sprintf("%d. %s - %d days (best %d)\n", position, mention(userID), currentStreak, maxStreak)
+	}
+
+	if position == 0 {
+		output += "Nobody is on a streak right now!"
+	}
+	return output, nil
+}
+
+// Wait for midnight on the 1st of each month (in the bot's timezone) and announce the month that just ended
+func (b *Bot) scheduleMonthlyAnnouncements(channelID string) {
+	for {
+		now := b.now()
+		nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, b.loc)
+		<-time.After(time.Until(nextMonth))
+
+		b.announceMonthlyStandings(channelID, monthlyPeriod(nextMonth.AddDate(0, -1, 0)))
+	}
+}
+
+// Post the final standings for a month to the announcement channel
+func (b *Bot) announceMonthlyStandings(channelID string, period leaderboardPeriod) {
+	channel, err := b.session.Channel(channelID)
+	if err != nil {
+		slog.Error("Error looking up announcement channel", "err", err)
+		return
+	}
+
+	slog.Info("Posting final monthly standings", "month", period.label, "channel", channelID)
+	if err := sendMessage(b.session, channelID, fmt.Sprintf("🗓️ **%s is over!** Here are the final standings:", period.label)); err != nil {
+		return
+	}
+	b.sendLeaderboard(b.session, channelID, b.boardFor(channel.GuildID, channelID), b.postedPeriod(period))
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Discord's limit on all the text of one embed together
+const embedTotalLimit = 6000
+
+// A board of n players with user IDs counting from 100000000000000000, who
+// all played one day
+func bigBoard(t *testing.T, b *Bot, n int) board {
+	t.Helper()
+	bd := b.boardFor("guild", "")
+	day := make(map[string]int, n)
+	for i := range n {
+		day[fmt.Sprint(100000000000000000+i)] = 1 + i%6
+	}
+	recordDays(t, b, bd, day)
+	return bd
+}
+
+func TestSixtyPlayerLeaderboardFitsDiscordLimits(t *testing.T) {
+	b := newTestBot(t)
+	bd := bigBoard(t, b, 60)
+
+	embeds, err := b.buildLeaderboard(bd, allTimePeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := 0
+	lines := 0
+	for i, embed := range embeds {
+		if len(embed.Fields) > embedFieldsPerEmbed {
+			t.Errorf("embed %d has %d fields, want at most %d", i, len(embed.Fields), embedFieldsPerEmbed)
+		}
+		total := len(embed.Title) + len(embed.Description) + len(embed.Footer.Text)
+		for _, field := range embed.Fields {
+			if len(field.Value) > embedFieldLimit {
+				t.Errorf("embed %d has a field of %d characters, want at most %d", i, len(field.Value), embedFieldLimit)
+			}
+			if field.Name == "" || !strings.HasSuffix(field.Value, "\n") {
+				t.Errorf("embed %d has a field named %q that splits a line: %q", i, field.Name, field.Value)
+			}
+			total += len(field.Name) + len(field.Value)
+			lines += strings.Count(field.Value, "\n")
+		}
+		if total > embedTotalLimit {
+			t.Errorf("embed %d has %d characters, want at most %d", i, total, embedTotalLimit)
+		}
+		fields += len(embed.Fields)
+	}
+	if fields < 2 {
+		t.Errorf("leaderboard packed into %d field(s), want it split", fields)
+	}
+	if lines != 60 {
+		t.Errorf("leaderboard lists %d players, want 60", lines)
+	}
+	if first := embeds[0].Fields[0].Value; !strings.HasPrefix(first, "🥇 ") {
+		t.Errorf("leaderboard starts %q, want the gold medal", first[:min(len(first), 40)])
+	}
+}
+
+func TestLeaderboardPages(t *testing.T) {
+	b := newTestBot(t)
+	bd := bigBoard(t, b, 60)
+
+	for page := 1; page <= 3; page++ {
+		embed, err := b.buildLeaderboardPage(bd, allTimePeriod, page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := 0
+		for _, field := range embed.Fields {
+			if len(field.Value) > embedFieldLimit {
+				t.Errorf("page %d has a field of %d characters", page, len(field.Value))
+			}
+			lines += strings.Count(field.Value, "\n")
+		}
+		if lines != leaderboardPageSize {
+			t.Errorf("page %d lists %d players, want %d", page, lines, leaderboardPageSize)
+		}
+		want := fmt.Sprintf("Page %d of 3", page)
+		if !strings.HasPrefix(embed.Footer.Text, want) {
+			t.Errorf("page %d footer = %q, want it to start %q", page, embed.Footer.Text, want)
+		}
+		// Medals are for the top 3, who are on the first page
+		medal := strings.HasPrefix(embed.Fields[0].Value, "🥇 ")
+		if medal != (page == 1) {
+			t.Errorf("page %d starts %q", page, strings.SplitN(embed.Fields[0].Value, "\n", 2)[0])
+		}
+	}
+
+	embed, err := b.buildLeaderboardPage(bd, allTimePeriod, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(embed.Fields) != 0 || !strings.Contains(embed.Description, "doesn't exist") {
+		t.Errorf("page 4 = %q with %d fields, want a missing page notice", embed.Description, len(embed.Fields))
+	}
+}
+
+func TestCompetitionRanks(t *testing.T) {
+	entry := func(total, days int) leaderboardEntry {
+		return leaderboardEntry{totalScore: total, daysPlayed: days}
+	}
+	tests := []struct {
+		name    string
+		entries []leaderboardEntry
+		want    []int
+	}{
+		{"no ties", []leaderboardEntry{entry(2, 1), entry(3, 1), entry(4, 1)}, []int{1, 2, 3}},
+		{"tie for second", []leaderboardEntry{entry(2, 1), entry(3, 1), entry(6, 2), entry(4, 1)}, []int{1, 2, 2, 4}},
+		{"everyone tied", []leaderboardEntry{entry(3, 1), entry(6, 2), entry(9, 3)}, []int{1, 1, 1}},
+		{"same average over different days", []leaderboardEntry{entry(7, 3), entry(14, 6), entry(5, 2)}, []int{1, 1, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := competitionRanks(tt.entries); !slices.Equal(got, tt.want) {
+				t.Errorf("competitionRanks = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAverageCents(t *testing.T) {
+	tests := []struct {
+		total, days int
+		want        int
+		shown       string
+	}{
+		{7, 2, 350, "3.50"},
+		{14, 4, 350, "3.50"},
+		{7, 3, 233, "2.33"},
+		{14, 6, 233, "2.33"},
+		{701, 200, 351, "3.51"}, // 3.505 rounds half up
+		{699, 200, 350, "3.50"}, // 3.495 too
+		{10, 3, 333, "3.33"},
+		{20, 3, 667, "6.67"},
+	}
+	for _, tt := range tests {
+		e := leaderboardEntry{totalScore: tt.total, daysPlayed: tt.days}
+		if got := e.averageCents(); got != tt.want {
+			t.Errorf("averageCents of %d/%d = %d, want %d", tt.total, tt.days, got, tt.want)
+		}
+		if got := e.shownAverage(); got != tt.shown {
+			t.Errorf("shownAverage of %d/%d = %q, want %q", tt.total, tt.days, got, tt.shown)
+		}
+
+		// The leaderboard shows the days played as the tiebreak, and !stats
+		// the same average
+		if got, want := sortAverage.metric(e), fmt.Sprintf("%s (%d days)", tt.shown, tt.days); got != want {
+			t.Errorf("metric of %d/%d = %q, want %q", tt.total, tt.days, got, want)
+		}
+		stats := formatUserStats("111", &userStats{totalScore: tt.total, daysPlayed: tt.days})
+		if want := "Average: " + tt.shown; !strings.Contains(stats, want) {
+			t.Errorf("stats of %d/%d = %q, want them to show %q", tt.total, tt.days, stats, want)
+		}
+	}
+}
+
+func TestZeroDayAverages(t *testing.T) {
+	for _, e := range []leaderboardEntry{
+		{totalScore: 0, daysPlayed: 0},
+		{totalScore: 14, daysPlayed: 0},
+		{totalScore: 14, daysPlayed: -1},
+	} {
+		if got := e.average(); got != 0 {
+			t.Errorf("average of %d/%d = %v, want 0", e.totalScore, e.daysPlayed, got)
+		}
+		if got := e.averageCents(); got != 0 {
+			t.Errorf("averageCents of %d/%d = %d, want 0", e.totalScore, e.daysPlayed, got)
+		}
+		if got := e.shownAverage(); got != "0.00" {
+			t.Errorf("shownAverage of %d/%d = %q, want 0.00", e.totalScore, e.daysPlayed, got)
+		}
+	}
+}
+
+func TestZeroDayRowsLeftOffLeaderboard(t *testing.T) {
+	b := newTestBot(t)
+	bd := b.boardFor("guild", "")
+	recordDays(t, b, bd, map[string]int{"111": 3})
+
+	// A row penalized without ever playing, as older versions could leave
+	if _, err := b.db.Exec("INSERT INTO leaderboard (guild_id, channel_id, user_id, username, score, days_played) VALUES (?, ?, ?, ?, ?, ?)", bd.guildID, bd.channelID, "222", "", 14, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []standing{{"111", 3, 1, 1}}
+	for _, period := range []leaderboardPeriod{allTimePeriod, weeklyPeriod(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))} {
+		for _, by := range []leaderboardSort{sortAverage, sortTotal, sortWins, sortStreak} {
+			period.sort = by
+			if got := standings(t, b, bd, period); !slices.Equal(got, want) {
+				t.Errorf("leaderboard since %q sorted by %s = %v, want %v", period.since, period.sort.label(), got, want)
+			}
+		}
+	}
+
+	lines, err := b.leaderboardLines(bd, allTimePeriod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range lines {
+		if strings.Contains(line, "NaN") || strings.Contains(line, "Inf") {
+			t.Errorf("leaderboard line %q", line)
+		}
+	}
+
+	// Nor do such rows make it into an archived season
+	sn, err := b.archiveSeason(bd, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entries, err := b.seasonEntries(sn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].userID != "111" {
+		t.Errorf("season standings = %v, want only 111", entries)
+	}
+}
+
+func TestStandingsAsOfDate(t *testing.T) {
+	b := newTestBot(t)
+	bd := b.boardFor("guild", "")
+	recordDays(t, b, bd,
+		map[string]int{"111": 4, "222": 3},
+		map[string]int{"111": 2, "222": 4},
+		map[string]int{"111": 6, "222": 2},
+	)
+	now := time.Date(2026, 1, 3, 12, 0, 0, 0, time.UTC)
+
+	period, _, err := parseLeaderboardArgs([]string{"on", testDate(1)}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []standing{{"111", 6, 2, 1}, {"222", 7, 2, 2}}
+	if got := standings(t, b, bd, period); !slices.Equal(got, want) {
+		t.Errorf("standings as of %s = %v, want %v", testDate(1), got, want)
+	}
+	if got := periodRange(period); got != "Through Jan 2, 2026" {
+		t.Errorf("range = %q, want Through Jan 2, 2026", got)
+	}
+
+	for _, arg := range []string{"2026-13-01", testDate(3)} {
+		if _, _, err := parseLeaderboardArgs([]string{"on", arg}, now); err == nil {
+			t.Errorf("on %s accepted", arg)
+		}
+	}
+}
+
+func TestStandingsLinesNames(t *testing.T) {
+	b := newBot(nil)
+	entries := []leaderboardEntry{
+		{userID: "123456789012345678", username: "a_b*", totalScore: 3, daysPlayed: 1},
+		{userID: "zoë", username: "Zoë", totalScore: 4, daysPlayed: 1},
+		{userID: "sam", totalScore: 5, daysPlayed: 1},
+		{userID: "223456789012345678", totalScore: 6, daysPlayed: 1},
+	}
+	// Only user IDs are mentioned, and only when plain is off; a player
+	// without a cached name falls back to the mention
+	tests := []struct {
+		plain bool
+		want  []string // how each entry is named
+	}{
+		{false, []string{"<@123456789012345678>", "**Zoë**", " sam ", "<@223456789012345678>"}},
+		{true, []string{`**a\_b\***`, "**Zoë**", " sam ", "<@223456789012345678>"}},
+	}
+	for _, tt := range tests {
+		lines := b.standingsLines(entries, sortAverage, tt.plain)
+		for i, line := range lines {
+			if !strings.Contains(line, tt.want[i]) {
+				t.Errorf("plain=%v: line %q doesn't name the player as %q", tt.plain, line, tt.want[i])
+			}
+			mentions := strings.Count(line, "<@")
+			if want := strings.Count(tt.want[i], "<@"); mentions != want {
+				t.Errorf("plain=%v: line %q has %d mention(s), want %d", tt.plain, line, mentions, want)
+			}
+		}
+	}
+}
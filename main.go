@@ -1,293 +1,408 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
 	"os"
-	"regexp"
+	"os/signal"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
+	_ "time/tzdata" // Lets TIMEZONE work on hosts without a zoneinfo database
 
 	"github.com/bwmarrin/discordgo"
 	"github.com/joho/godotenv"
 	_ "modernc.org/sqlite" // SQLite Driver
 )
 
-// Global database connection
-var db *sql.DB
+// Bot holds the database and Discord session shared by every handler
+type Bot struct {
+	db        *sql.DB
+	store     store // the DB_DRIVER backend db belongs to
+	session   *discordgo.Session
+	stmts     statements
+	prefix    string // command prefix, e.g. "!" in "!leaderboard"
+	penalties penalties
+	adminIDs  map[string]bool // ADMIN_USER_IDS, allowed admin commands on top of Manage Server
+	loc       *time.Location  // TIMEZONE that puzzle dates and periods are computed in
 
-func main() {
-	// Load .env file
-	err := godotenv.Load()
-	if err != nil {
-		fmt.Println("Error loading .env file:", err)
-	}
+	// WORDLE_BOTS and WORDLE_BOT_USER_ID; accounts whose results messages
+	// are accepted, the official Wordle bot by default
+	wordleBots []botIdentity
 
-	// Connect to SQLite
-	db, err = sql.Open("sqlite", "./leaderboard.db")
-	if err != nil {
-		fmt.Println("Error connecting to database:", err)
-		return
-	}
-	defer db.Close()
+	// WORDLE_WEBHOOK_IDS; webhooks that relay the Wordle bot's messages,
+	// empty to recognise relayed results by name or content
+	wordleWebhookIDs map[string]bool
 
-	// Create a database table if it doesn't already exist
-	initializeDatabase()
+	// WATCHED_CHANNEL_IDS; when set, only these channels' results are
+	// recorded and each channel has its own leaderboard
+	watchedChannels map[string]bool
 
-	// Get bot token from environment
-	botToken := os.Getenv("DISCORD_BOT_TOKEN")
-	if botToken == "" {
-		fmt.Println("Bot token not set!")
-		return
-	}
+	// Held around every change to the database, so concurrent handlers
+	// can't interleave a check with the write that depends on it (such as
+	// the duplicate check before recording results). Reads don't take it.
+	writeMu sync.Mutex
 
-	// Create a new Discord session
-	dg, err := discordgo.New("Bot " + botToken)
-	if err != nil {
-		fmt.Println("Error creating Discord session:", err)
-		return
-	}
+	// Leaderboard messages that can be paged with reactions
+	pagers pagers
 
-	// Register message handler
-	dg.AddHandler(onMessageCreate)
+	// BARE_NAME_FALLBACK; match display names on score lines when a results
+	// message has no mentions at all
+	bareNames bool
 
-	// Open the bot connection
-	err = dg.Open()
-	if err != nil {
-		fmt.Println("Error opening connection:", err)
-		return
-	}
-	defer dg.Close()
+	// WOODEN_SPOON; also name the day's worst score in the announcement
+	woodenSpoon bool
+
+	// LEADERBOARD_EDIT_IN_PLACE; edit the channel's last leaderboard post
+	// rather than posting a new one each time
+	editLeaderboards bool
+
+	// GRID_CHECK; warn when a score disagrees with the emoji grid posted
+	// alongside it
+	gridCheck bool
+
+	// NO_RESULTS_ACK and NO_RESULTS_LEADERBOARD; skip the "successfully
+	// processed" message and the leaderboard posted after each results message
+	noResultsAck         bool
+	noResultsLeaderboard bool
+
+	// LEADERBOARD_PINGS; mention players on the leaderboards the bot posts
+	// after results and each month, rather than showing their names
+	leaderboardPings bool
+
+	// TRACK_DEPARTURES; ask for the Server Members intent so players who
+	// leave the server are set inactive
+	trackDepartures bool
+
+	// Short command names (alias -> command), defaults plus COMMAND_ALIASES
+	aliases map[string]string
+
+	// COMMAND_COOLDOWN; how long a user waits between two uses of the same
+	// command (0 disables), and the uses being tracked
+	cooldown  time.Duration
+	cooldowns cooldowns
+
+	// LEADERBOARD_MEDALS, LEADERBOARD_COLOR and STREAK_EMOJIS
+	theme theme
+
+	// SCORING_MODE and POINTS_MAP; golf unless points are configured
+	scoring scoring
 
-	fmt.Println("Bot is running. Press CTRL+C to exit.")
-	select {} // Keep the bot running until interrupted
+	// LOG_CHANNEL_ID; channel warned when a results message can't be
+	// parsed, empty to only log it
+	logChannelID string
+
+	// WINNER_ROLE_ID; role given to the all-time leader after each processed
+	// day, empty to not hand out a role
+	winnerRoleID string
+
+	// When the process started, for the uptime in !status
+	startedAt time.Time
+
+	// Counters served on METRICS_ADDR
+	metrics metrics
 }
 
-// Create the database table
-func initializeDatabase() {
-	createTableSQL := `
-    CREATE TABLE IF NOT EXISTS leaderboard (
-        id INTEGER PRIMARY KEY AUTOINCREMENT,
-        username TEXT NOT NULL UNIQUE,
-        score INTEGER NOT NULL,
-		days_played INTEGER NOT NULL DEFAULT 0
-    );`
-	_, err := db.Exec(createTableSQL)
-	if err != nil {
-		fmt.Println("Error creating table:", err)
+// Create a bot backed by an open store, nil for one that never touches the
+// database
+func newBot(st store) *Bot {
+	b := &Bot{store: st, prefix: defaultCommandPrefix, penalties: penalties{fail: failScore, miss: failScore}, loc: time.Local, wordleBots: defaultWordleBots, aliases: maps.Clone(defaultAliases), cooldown: defaultCommandCooldown, theme: defaultTheme(), scoring: defaultScoring()}
+	if st != nil {
+		b.db = st.DB()
 	}
+	return b
 }
 
-// Handle received messages
-func onMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Ignore the bot's own messages
-	if m.Author.ID == s.State.User.ID {
-		return
-	}
+// The current time in the bot's timezone
+func (b *Bot) now() time.Time {
+	return time.Now().In(b.loc)
+}
 
-	// Command to display all-time leaderboard
-	if strings.HasPrefix(strings.ToLower(m.Content), "!leaderboard") {
-		sendLeaderboard(s, m.ChannelID)
+// Create a text logger writing to stderr at the given level ("debug",
+// "info", "warn" or "error"); unknown or empty levels mean info
+func newLogger(level string) *slog.Logger {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(strings.TrimSpace(level))); err != nil {
+		l = slog.LevelInfo
 	}
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: l}))
+}
 
-	// Debug: Log the received message
-	fmt.Printf("Message received from %s: %s\n", m.Author.Username, m.Content)
+// Parse a comma or space separated list of Discord IDs into a set
+func parseIDList(list string) map[string]bool {
+	ids := make(map[string]bool)
+	for _, id := range strings.FieldsFunc(list, func(r rune) bool { return r == ',' || r == ' ' }) {
+		ids[id] = true
+	}
+	return ids
+}
 
-	if m.Author.Username == "Wordle" && m.Author.Discriminator == "2092" {
-		// Additional check: Look for "results" in the content
-		if strings.Contains(strings.ToLower(m.Content), "results") {
-			fmt.Printf("Processing results message from Wordle#2092: %s\n", m.Content)
-			processWordleResultsMessage(m.Content, s, m.ChannelID)
+func main() {
+	startedAt := time.Now() // Reported as uptime by !status
+	importPath := flag.String("import", "", "backfill past results from a CSV or JSON `file`, then exit")
+	importGuild := flag.String("guild", "", "guild ID to import results into (with -import)")
+	importChannel := flag.String("channel", "", "channel ID to import into when WATCHED_CHANNEL_IDS is set (with -import)")
+	configPath := flag.String("config", "", "read settings from a JSON `file`, taking precedence over the environment")
+	flag.Parse()
+
+	// Load .env file and the -config file over it, then configure logging
+	// from them. Settings come from the config file first, then the
+	// environment, then the defaults.
+	envErr := godotenv.Load()
+	var configErr error
+	var overridden []string
+	if *configPath != "" {
+		var cfg Config
+		if cfg, configErr = loadConfigFile(*configPath); configErr == nil {
+			overridden, configErr = cfg.apply()
 		}
-	} else {
-		if strings.Contains(strings.ToLower(m.Content), "results") {
-			fmt.Println("Message ignored. Not from Wordle #2092.")
+	}
+	slog.SetDefault(newLogger(os.Getenv("LOG_LEVEL")))
+	if envErr != nil {
+		slog.Warn("Error loading .env file", "err", envErr)
+	}
+	if configErr != nil {
+		slog.Error("Invalid config file, not starting", "path", *configPath, "err", configErr)
+		os.Exit(1)
+	}
+	if *configPath != "" {
+		slog.Info("Loaded config file", "path", *configPath, "overriding", overridden)
+	}
+
+	// Report every configuration problem at once and refuse to start. An
+	// unknown DB_DRIVER, or a DATABASE_URL without DB_DRIVER=postgres, is
+	// refused rather than silently writing to a local SQLite file the
+	// operator didn't ask for.
+	problems := checkEnvironment(*importPath != "")
+	if *importPath != "" && *importGuild == "" {
+		problems = append(problems, "-import needs -guild")
+	}
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			slog.Error("Configuration problem", "problem", problem)
 		}
+		slog.Error("Invalid configuration, not starting", "problems", len(problems))
+		os.Exit(1)
 	}
 
-	// if strings.Contains(strings.ToLower(m.Content), "results") {
-	// 	fmt.Printf("Processing results message from Wordle#2092: %s\n", m.Content)
-	// 	processWordleResultsMessage(m.Content, s, m.ChannelID)
-	// }
+	if err := run(startedAt, *importPath, *importGuild, *importChannel); err != nil {
+		slog.Error("Stopped on an error", "err", err)
+		os.Exit(1)
+	}
 }
 
-// Parse Wordle messages and update the database
-func processWordleResultsMessage(message string, s *discordgo.Session, channelID string) {
-	// Split the message into lines by newline
-	lines := strings.Split(message, "\n")
-
-	// Regex patterns for scores and usernames
-	scoreRegex := regexp.MustCompile(`(\d+)/6|X/6`) // Matches "1/6", "2/6", etc.
-	userRegex := regexp.MustCompile(`@\S+`)         // Matches "@username"
-
-	// Track all users in the daily results
-	dailyUsers := make(map[string]int) // username -> score
-
-	// Parse the message
-	for _, line := range lines {
-		// Check if the line contains a score match
-		scoreMatch := scoreRegex.FindString(line)
-		if scoreMatch != "" {
-			score := 0
-			// Extract the numeric score
-			if strings.HasPrefix(scoreMatch, "X") {
-				score = 7 // X/6 gets 7 penalty points
-			} else {
-				score, _ = strconv.Atoi(strings.Split(scoreMatch, "/")[0]) // e.g., "3/6" -> 3
-			}
+// Set up the bot from the checked configuration and run it until
+// interrupted, or with importPath until the import is done. Returns an error
+// for anything that stops it starting, once the deferred closes have run,
+// so main can exit non-zero.
+func run(startedAt time.Time, importPath, importGuild, importChannel string) error {
+	// Connect to the DB_DRIVER backend
+	st, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer st.DB().Close()
 
-			// Extract usernames from the line
-			usernames := userRegex.FindAllString(line, -1)
-			for _, user := range usernames {
-				user = cleanUsername(user) // Normalize the username
-				dailyUsers[user] = score   // Add user to the daily user map
-			}
-		}
+	// Create the database tables if they don't already exist
+	bot := newBot(st)
+	bot.startedAt = startedAt
+	if err := bot.initializeDatabase(); err != nil {
+		return fmt.Errorf("initializing database: %w", err)
+	}
+	if err := bot.prepareStatements(); err != nil {
+		return fmt.Errorf("preparing statements: %w", err)
 	}
 
-	// Debug: Log daily users
-	fmt.Println("Daily Wordle results:", dailyUsers)
+	// Read the command prefix, falling back to "!", and any extra aliases
+	bot.prefix = parseCommandPrefix(os.Getenv("COMMAND_PREFIX"))
+	bot.aliases, err = parseCommandAliases(os.Getenv("COMMAND_ALIASES"))
+	if err != nil {
+		return fmt.Errorf("invalid command aliases: %w", err)
+	}
 
-	// Update scores in the database
-	updateScoresBasedOnResults(dailyUsers)
+	// Read the command cooldown, e.g. "10s", "1m" or "0" to turn it off
+	if raw := strings.TrimSpace(os.Getenv("COMMAND_COOLDOWN")); raw != "" {
+		bot.cooldown, err = parseCooldown(raw)
+		if err != nil {
+			return err
+		}
+	}
+	slog.Info("Command cooldown", "cooldown", bot.cooldown)
 
-	// Send acknowledgment that results were processed
-	s.ChannelMessageSend(channelID, "Daily results successfully processed!")
-	sendLeaderboard(s, channelID)
-}
+	// Medals, colour and streak emojis of the leaderboard
+	bot.theme, err = loadTheme()
+	if err != nil {
+		return fmt.Errorf("invalid theme: %w", err)
+	}
 
-// Helper method to clean and format usernames
-func cleanUsername(username string) string {
-	username = strings.TrimSpace(username)
-	username = strings.Trim(username, "@<>") // Remove leading "@" if present
-	return username
-}
+	// Read the penalty points, refusing to start with invalid values
+	bot.penalties, err = loadPenalties()
+	if err != nil {
+		return fmt.Errorf("invalid penalty settings: %w", err)
+	}
+	slog.Info("Penalty points", "fail", bot.penalties.fail, "miss", bot.penalties.miss)
 
-func updateScoresBasedOnResults(dailyUsers map[string]int) {
-	// Get all users already in the database
-	rows, err := db.Query("SELECT username FROM leaderboard")
+	// Golf totals or points
+	bot.scoring, err = loadScoring()
 	if err != nil {
-		fmt.Println("Error querying database for users:", err)
-		return
+		return fmt.Errorf("invalid scoring settings: %w", err)
 	}
-	defer rows.Close()
+	slog.Info("Scoring", "mode", bot.scoring.mode, "points", bot.scoring.points[1:], "miss", bot.scoring.miss, "tiebreak", bot.scoring.tiebreak)
 
-	// Build a set of all users in the database
-	dbUsers := make(map[string]bool)
-	for rows.Next() {
-		var username string
-		err := rows.Scan(&username)
+	// Channels whose results are recorded, each on its own leaderboard
+	bot.watchedChannels = parseIDList(os.Getenv("WATCHED_CHANNEL_IDS"))
+	if len(bot.watchedChannels) > 0 {
+		slog.Info("Keeping per-channel leaderboards", "channels", len(bot.watchedChannels))
+	}
+
+	// Puzzle dates follow the group's local midnight rather than the server's
+	if tz := strings.TrimSpace(os.Getenv("TIMEZONE")); tz != "" {
+		bot.loc, err = time.LoadLocation(tz)
 		if err != nil {
-			fmt.Println("Error scanning database row:", err)
-			continue
+			return fmt.Errorf("invalid TIMEZONE %q: %w", tz, err)
 		}
-		dbUsers[username] = true // Mark the user as existing in the database
 	}
+	slog.Info("Using timezone", "timezone", bot.loc.String())
 
-	// Process the daily results (update cumulative scores and mark processed users)
-	for user, score := range dailyUsers {
-		updateCumulativeScore(user, score, true) // Mark as a scored day
-		dbUsers[user] = false                    // Mark this user as "processed" (present in results)
-	}
+	// Users allowed to run admin commands regardless of their server permissions
+	bot.adminIDs = parseIDList(os.Getenv("ADMIN_USER_IDS"))
 
-	// Add 7-point penalties for users not in daily results
-	for user, present := range dbUsers {
-		if present {
-			fmt.Printf("Adding penalty for %s (absent in daily results)\n", user)
-			updateCumulativeScore(user, 7, false) // Penalty without incrementing days
-		}
+	// Accounts recognised as the Wordle bot, such as a mock one in staging
+	bot.wordleBots, err = loadWordleBots()
+	if err != nil {
+		return fmt.Errorf("invalid Wordle bot list: %w", err)
 	}
-}
+	slog.Info("Accepting results from", "bots", bot.wordleBots)
+	bot.wordleWebhookIDs = parseIDList(os.Getenv("WORDLE_WEBHOOK_IDS"))
 
-func updateCumulativeScore(username string, score int, incrementDays bool) {
-	var currentScore, daysPlayed int
+	// Role handed to whoever leads the leaderboard
+	bot.winnerRoleID = strings.TrimSpace(os.Getenv("WINNER_ROLE_ID"))
 
-	// Check if the user already exists in the database
-	err := db.QueryRow("SELECT score, days_played FROM leaderboard WHERE username = ?", username).Scan(&currentScore, &daysPlayed)
-	if err == sql.ErrNoRows {
-		// If the user doesn't exist, insert them with their current score and 1 day played
-		newDaysPlayed := 0
-		if incrementDays {
-			newDaysPlayed = 1
+	// Channel told about results messages the parser couldn't read
+	bot.logChannelID = strings.TrimSpace(os.Getenv("LOG_CHANNEL_ID"))
+
+	// Optional behaviour switches, all off unless set to true
+	for _, toggle := range bot.toggles() {
+		raw := strings.TrimSpace(os.Getenv(toggle.name))
+		if raw == "" {
+			continue
 		}
-		_, err := db.Exec("INSERT INTO leaderboard (username, score, days_played) VALUES (?, ?, ?)", username, score, newDaysPlayed)
+		*toggle.value, err = strconv.ParseBool(raw)
 		if err != nil {
-			fmt.Println("Error inserting new user:", err)
-		}
-	} else if err == nil {
-		// If the user exists, update their total score
-		newTotal := currentScore + score
-		newDaysPlayed := daysPlayed
-		if incrementDays {
-			newDaysPlayed += 1
+			return fmt.Errorf("%s must be true or false, got %q", toggle.name, raw)
 		}
-		_, err := db.Exec("UPDATE leaderboard SET score = ?, days_played = ? WHERE username = ?", newTotal, newDaysPlayed, username)
+		slog.Info("Setting", "setting", toggle.name, "enabled", *toggle.value)
+	}
+
+	// With -import, backfill the file and exit without connecting to Discord
+	if importPath != "" {
+		imported, skipped, err := bot.importResults(bot.boardFor(importGuild, importChannel), importPath)
 		if err != nil {
-			fmt.Println("Error updating user score and days played:", err)
+			return fmt.Errorf("importing %s, stopped after %d rows: %w", importPath, imported, err)
 		}
-	} else {
-		fmt.Println("Error querying user:", err)
+		slog.Info("Imported results", "file", importPath, "rows", imported, "skipped_days", skipped)
+		return nil
 	}
-}
 
-// Fetch and send the leaderboard
-func sendLeaderboard(s *discordgo.Session, channelID string) {
-	// Query leaderboard data
-	rows, err := db.Query("SELECT username, score, days_played FROM leaderboard WHERE days_played > 0 ORDER BY (score * 1.0 / days_played) ASC, days_played DESC, username ASC")
+	// Get bot token from environment (checked above)
+	botToken := strings.TrimSpace(os.Getenv("DISCORD_BOT_TOKEN"))
+
+	// Create a new Discord session
+	dg, err := discordgo.New("Bot " + botToken)
 	if err != nil {
-		fmt.Println("Error fetching leaderboard:", err)
-		return
+		return fmt.Errorf("creating Discord session: %w", err)
 	}
-	defer rows.Close()
 
-	output := "📊 **Wordle Leaderboard (Average Score)** 📊\n"
+	bot.session = dg
 
-	var (
-		rank     = 0    // current displayed rank
-		position = 0    // row index
-		prevAvg  = -1.0 // last average score
-	)
+	// Register message and slash command handlers
+	dg.AddHandler(bot.onMessageCreate)
+	dg.AddHandler(bot.onMessageUpdate)
+	dg.AddHandler(bot.onInteractionCreate)
+	dg.AddHandler(bot.onMessageReactionAdd)
+	dg.AddHandler(bot.onGuildMemberRemove)
+	dg.AddHandler(bot.onGuildMemberAdd)
 
-	for rows.Next() {
-		var username string
-		var totalScore, daysPlayed int
-		if err := rows.Scan(&username, &totalScore, &daysPlayed); err != nil {
-			fmt.Println("Error scanning leaderboard row:", err)
-			continue
-		}
+	// Member join and leave events need the privileged Server Members
+	// intent, which must also be enabled for the bot in the developer portal
+	if bot.trackDepartures {
+		dg.Identify.Intents |= discordgo.IntentsGuildMembers
+	}
 
-		averageScore := float64(totalScore) / float64(daysPlayed)
-		position++
+	// Open the bot connection
+	err = dg.Open()
+	if err != nil {
+		return fmt.Errorf("opening Discord connection: %w", err)
+	}
+	defer dg.Close()
 
-		// If this score is different from the previous one, update rank to *position*
-		if averageScore != prevAvg {
-			rank = position
-			prevAvg = averageScore
-		}
+	// Register slash commands now that the bot user is known
+	registerSlashCommands(dg)
 
-		var medal string
-		switch rank {
-		case 1:
-			medal = "🥇"
-		case 2:
-			medal = "🥈"
-		case 3:
-			medal = "🥉"
-		default:
-			medal = fmt.Sprintf("%d.", rank)
-		}
+	// Post the final monthly standings on the 1st if a channel is configured
+	if channelID := os.Getenv("MONTHLY_ANNOUNCEMENT_CHANNEL_ID"); channelID != "" {
+		go bot.scheduleMonthlyAnnouncements(channelID)
+	}
 
-		output += fmt.Sprintf("%s <@%s> - %.2f\n", medal, username, averageScore)
+	// Remind players who haven't played yet, at REMINDER_TIME (default 21:00)
+	if channelID := strings.TrimSpace(os.Getenv("REMINDER_CHANNEL_ID")); channelID != "" {
+		reminderTime := os.Getenv("REMINDER_TIME")
+		if reminderTime == "" {
+			reminderTime = "21:00"
+		}
+		hour, minute, err := parseReminderTime(reminderTime)
+		if err != nil {
+			return err
+		}
+		slog.Info("Sending daily reminders", "channel", channelID, "time", reminderTime)
+		go bot.scheduleReminders(channelID, hour, minute)
 	}
 
-	// If no rows are found, notify the channel
-	if position == 0 {
-		output += "No results available yet!"
+	// Publish the leaderboard over HTTP if an address is configured
+	if addr := os.Getenv("HTTP_ADDR"); addr != "" {
+		srv := bot.newHTTPServer(addr)
+		go func() {
+			slog.Info("Serving leaderboard over HTTP", "addr", addr)
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("HTTP server stopped", "err", err)
+			}
+		}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(ctx)
+		}()
 	}
 
-	// Send the message to the Discord channel
-	_, err = s.ChannelMessageSend(channelID, output)
-	if err != nil {
-		fmt.Println("Error sending leaderboard:", err)
+	// Expose metrics for monitoring if an address is configured
+	if addr := os.Getenv("METRICS_ADDR"); addr != "" {
+		srv := bot.newMetricsServer(addr)
+		go func() {
+			slog.Info("Serving metrics over HTTP", "addr", addr)
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				slog.Error("Metrics server stopped", "err", err)
+			}
+		}()
+		defer func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			srv.Shutdown(ctx)
+		}()
 	}
+
+	slog.Info("Bot is running. Press CTRL+C to exit.")
+
+	// Keep the bot running until interrupted, then let the deferred closes run
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	sig := <-stop
+	slog.Info("Shutting down", "signal", sig.String())
+	return nil
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Set a user's leaderboard rows in a guild active or inactive, on every
+// board and game. Inactive players are left off the standings and get no
+// miss penalties, but keep their history. Returns the rows changed.
+func (b *Bot) setActive(guildID, userID string, active bool) (int64, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	state := 0
+	if active {
+		state = 1
+	}
+	res, err := b.db.Exec("UPDATE leaderboard SET active = ? WHERE guild_id = ? AND user_id = ? AND active <> ?", state, guildID, userID, state)
+	if err != nil {
+		return 0, fmt.Errorf("updating active flag of %s: %w", userID, err)
+	}
+	return res.RowsAffected()
+}
+
+// A member left the server (or was kicked or banned): stop ranking and
+// penalizing them until they come back
+func (b *Bot) onGuildMemberRemove(s *discordgo.Session, m *discordgo.GuildMemberRemove) {
+	if m.Member == nil || m.User == nil {
+		return
+	}
+	n, err := b.setActive(m.GuildID, m.User.ID, false)
+	if err != nil {
+		slog.Error("Error deactivating departed member", "err", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("Deactivated departed member", "guild", m.GuildID, "user", m.User.ID, "boards", n)
+		return
+	}
+
+	// Rows are matched on the user ID only; a name-keyed legacy entry can't
+	// be tied to the member reliably, so it is reported rather than guessed
+	legacy, err := b.legacyEntries(m.GuildID, m.User.Username, m.User.GlobalName, m.Nick)
+	if err != nil {
+		slog.Error("Error looking for legacy entries of departed member", "err", err)
+		return
+	}
+	if len(legacy) > 0 {
+		slog.Warn("Departed member may have name-keyed entries, combine them into the user ID with !merge so departures are tracked", "guild", m.GuildID, "user", m.User.ID, "entries", legacy)
+	} else {
+		slog.Debug("Departed member has no leaderboard entries", "guild", m.GuildID, "user", m.User.ID)
+	}
+}
+
+// The name-keyed (pre user ID) entries of a guild matching any of names
+func (b *Bot) legacyEntries(guildID string, names ...string) ([]string, error) {
+	var keys []any
+	for _, name := range names {
+		if key := normalizeName(name); key != "" && !isUserID(key) {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	in := strings.TrimSuffix(strings.Repeat("?, ", len(keys)), ", ")
+	rows, err := b.db.Query("SELECT DISTINCT user_id FROM leaderboard WHERE guild_id = ? AND user_id IN ("+in+") ORDER BY user_id", append([]any{guildID}, keys...)...)
+	if err != nil {
+		return nil, fmt.Errorf("loading legacy entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("scanning legacy entry: %w", err)
+		}
+		entries = append(entries, key)
+	}
+	return entries, rows.Err()
+}
+
+// A member joined the server: if they had left before, pick up where they
+// left off
+func (b *Bot) onGuildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
+	if m.Member == nil || m.User == nil {
+		return
+	}
+	n, err := b.setActive(m.GuildID, m.User.ID, true)
+	if err != nil {
+		slog.Error("Error reactivating returning member", "err", err)
+		return
+	}
+	if n > 0 {
+		slog.Info("Reactivated returning member", "guild", m.GuildID, "user", m.User.ID, "boards", n)
+	}
+}
+
+// Handle "!revive @user": make a player who left the server active again,
+// for when they rejoined while the bot wasn't watching
+func (b *Bot) handleReviveCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can revive players.")
+		return
+	}
+	args := commandArgs(m.Content)
+	if len(args) == 0 {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%srevive @user`", b.prefix))
+		return
+	}
+	userID := cleanUsername(args[0])
+
+	n, err := b.setActive(m.GuildID, userID, true)
+	if err != nil {
+		slog.Error("Error reviving player", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to revive that player.")
+		return
+	}
+	if n == 0 {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("%s isn't marked as having left.", mention(userID)))
+		return
+	}
+	slog.Info("Revived player", "guild", m.GuildID, "user", userID, "admin", m.Author.ID)
+	sendMessage(s, m.ChannelID, fmt.Sprintf("%s is back on the leaderboard with their history intact.", mention(userID)))
+}
+
+// The inactive players on a board, so a rebuild can keep them inactive
+func inactiveUsers(tx *sql.Tx, bd board) ([]string, error) {
+	rows, err := tx.Query("SELECT user_id FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND active = 0", bd.guildID, bd.channelID, bd.game)
+	if err != nil {
+		return nil, fmt.Errorf("loading inactive players: %w", err)
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scanning inactive player: %w", err)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
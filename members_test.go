@@ -0,0 +1,32 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDepartedPlayerSetInactive(t *testing.T) {
+	b := newTestBot(t)
+	bd := b.boardFor("guild", "")
+	member := &discordgo.Member{GuildID: bd.guildID, User: &discordgo.User{ID: "222"}}
+	recordDays(t, b, bd, map[string]int{"111": 3, "222": 4})
+
+	// Gone from the board and not penalized for the day they miss
+	b.onGuildMemberRemove(nil, &discordgo.GuildMemberRemove{Member: member})
+	if err := b.updateScoresBasedOnResults(bd, map[string]int{"111": 3}, map[string]string{}, resultDay{date: testDate(1)}); err != nil {
+		t.Fatal(err)
+	}
+	want := []standing{{"111", 6, 2, 1}}
+	if got := standings(t, b, bd, allTimePeriod); !slices.Equal(got, want) {
+		t.Errorf("standings after leaving = %v, want %v", got, want)
+	}
+
+	// Back with their history once they rejoin
+	b.onGuildMemberAdd(nil, &discordgo.GuildMemberAdd{Member: member})
+	want = []standing{{"111", 6, 2, 1}, {"222", 4, 1, 2}}
+	if got := standings(t, b, bd, allTimePeriod); !slices.Equal(got, want) {
+		t.Errorf("standings after rejoining = %v, want %v", got, want)
+	}
+}
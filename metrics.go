@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Counters exposed on /metrics for monitoring, kept in memory since the
+// process started
+type metrics struct {
+	messages      atomic.Int64 // results messages processed, new or edited
+	parseFailures atomic.Int64 // results messages no scores could be read from
+	dbErrors      atomic.Int64 // results or slash commands that failed on the database
+
+	mu       sync.Mutex
+	commands map[string]int64 // command name -> times run
+}
+
+// Count a run of a command, prefix or slash
+func (m *metrics) countCommand(name string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.commands == nil {
+		m.commands = make(map[string]int64)
+	}
+	m.commands[name]++
+}
+
+// HTTP server exposing the metrics, started when METRICS_ADDR is set
+func (b *Bot) newMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /metrics", b.handleMetrics)
+	return &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+	}
+}
+
+// Serve GET /metrics in the Prometheus text format
+func (b *Bot) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	var players int64
+	if err := b.db.QueryRow("SELECT COUNT(DISTINCT guild_id || ':' || user_id) FROM leaderboard WHERE active = 1").Scan(&players); err != nil {
+		slog.Error("Error counting players for metrics", "err", err)
+		b.metrics.dbErrors.Add(1)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+
+	var out strings.Builder
+	metric := func(name, kind, help string) {
+		fmt.Fprintf(&out, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	metric("wordle_messages_processed_total", "counter", "Results messages processed, new or edited.")
+	fmt.Fprintf(&out, "wordle_messages_processed_total %d\n", b.metrics.messages.Load())
+	metric("wordle_parse_failures_total", "counter", "Results messages no scores could be read from.")
+	fmt.Fprintf(&out, "wordle_parse_failures_total %d\n", b.metrics.parseFailures.Load())
+	metric("wordle_db_errors_total", "counter", "Results and slash commands that failed on the database.")
+	fmt.Fprintf(&out, "wordle_db_errors_total %d\n", b.metrics.dbErrors.Load())
+
+	metric("wordle_commands_total", "counter", "Commands handled, by command.")
+	b.metrics.mu.Lock()
+	for _, name := range slices.Sorted(maps.Keys(b.metrics.commands)) {
+		fmt.Fprintf(&out, "wordle_commands_total{command=%q} %d\n", name, b.metrics.commands[name])
+	}
+	b.metrics.mu.Unlock()
+
+	metric("wordle_players", "gauge", "Active players on the leaderboards, counted once per server.")
+	fmt.Fprintf(&out, "wordle_players %d\n", players)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if _, err := w.Write([]byte(out.String())); err != nil {
+		slog.Error("Error writing metrics", "err", err)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	b := newTestBot(t)
+	bd := b.boardFor("guild", "")
+	recordDays(t, b, bd, map[string]int{"111": 3, "222": 4})
+	recordDays(t, b, bd.forGame("connections"), map[string]int{"111": 1})
+	b.metrics.messages.Add(2)
+	b.metrics.countCommand("stats")
+	b.metrics.countCommand("leaderboard")
+	b.metrics.countCommand("stats")
+
+	rec := httptest.NewRecorder()
+	b.newMetricsServer("").Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"wordle_messages_processed_total 2\n",
+		"wordle_parse_failures_total 0\n",
+		`wordle_commands_total{command="leaderboard"} 1` + "\n" + `wordle_commands_total{command="stats"} 2` + "\n",
+		// 111 plays on two boards of the guild but counts once
+		"wordle_players 2\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+}
@@ -0,0 +1,481 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strconv"
+)
+
+// A schema change applied once to an existing database. Versions before the
+// meta table existed were upgraded ad hoc, so every migration must check the
+// schema first and do nothing if the change is already there.
+type migration struct {
+	description string
+	apply       func(tx *sql.Tx) error
+}
+
+// Ordered schema migrations; a database at schema_version N has had the first
+// N applied. Only ever append to this list.
+var migrations = []migration{
+	{"assign existing rows to DEFAULT_GUILD_ID", migrateToGuilds},
+	{"key users on their Discord ID", migrateToUserIDs},
+	{"add streak, fail, puzzle number and timestamp columns", addTrackingColumns},
+	{"key leaderboards on channel as well as guild", migrateToChannels},
+	{"drop rows for users who have never played", dropPenaltyOnlyUsers},
+	{"normalize name-keyed users", normalizeNameKeys},
+	{"let users opt themselves out", addOptOutColumn},
+	{"remember each player's rank for rank change alerts", addLastRankColumn},
+	{"record points alongside scores for points scoring", addPointsColumn},
+	{"track results per game", migrateToGames},
+	{"remember when each player last played", addLastPlayedColumn},
+	{"remember when each player joined", addJoinedDateColumn},
+	{"set players who left the server inactive", addActiveColumn},
+}
+
+// Key/value store for database metadata such as the schema version
+const createMetaSQL = `
+    CREATE TABLE IF NOT EXISTS meta (
+        key TEXT PRIMARY KEY,
+        value TEXT NOT NULL
+    );`
+
+// Apply every migration newer than the stored schema_version, each in its
+// own transaction together with the version bump
+func (b *Bot) migrate() error {
+	if _, err := b.db.Exec(createMetaSQL); err != nil {
+		return fmt.Errorf("creating meta table: %w", err)
+	}
+
+	version, err := b.schemaVersion()
+	if err != nil {
+		return err
+	}
+	for i := version; i < len(migrations); i++ {
+		m := migrations[i]
+		slog.Info("Applying database migration", "version", i+1, "description", m.description)
+
+		tx, err := b.db.Begin()
+		if err != nil {
+			return fmt.Errorf("starting migration %d: %w", i+1, err)
+		}
+		if err := m.apply(tx); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d (%s): %w", i+1, m.description, err)
+		}
+		_, err = tx.Exec("INSERT INTO meta (key, value) VALUES ('schema_version', ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value", strconv.Itoa(i+1))
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("recording schema version %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("committing migration %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Read the stored schema version, 0 for a database that predates it
+func (b *Bot) schemaVersion() (int, error) {
+	var value string
+	err := b.db.QueryRow("SELECT value FROM meta WHERE key = 'schema_version'").Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("reading schema version: %w", err)
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q: %w", value, err)
+	}
+	return version, nil
+}
+
+// Move rows stored before guild_id existed into DEFAULT_GUILD_ID
+func migrateToGuilds(tx *sql.Tx) error {
+	type step struct {
+		query string
+		args  []any
+	}
+
+	defaultGuildID := os.Getenv("DEFAULT_GUILD_ID")
+	var steps []step
+
+	// The old leaderboard had a UNIQUE username column, so it must be rebuilt
+	if ok, err := columnExists(tx, "leaderboard", "guild_id"); err != nil {
+		return err
+	} else if !ok {
+		steps = append(steps,
+			step{query: "ALTER TABLE leaderboard RENAME TO leaderboard_old"},
+			step{query: createLeaderboardSQL},
+			step{query: "INSERT INTO leaderboard (id, guild_id, user_id, username, score, days_played) SELECT id, ?, username, username, score, days_played FROM leaderboard_old", args: []any{defaultGuildID}},
+			step{query: "DROP TABLE leaderboard_old"},
+		)
+	}
+	if ok, err := columnExists(tx, "daily_results", "guild_id"); err != nil {
+		return err
+	} else if !ok {
+		steps = append(steps,
+			step{query: "ALTER TABLE daily_results ADD COLUMN guild_id TEXT NOT NULL DEFAULT ''"},
+			step{query: "UPDATE daily_results SET guild_id = ?", args: []any{defaultGuildID}},
+			step{query: "DROP INDEX IF EXISTS idx_daily_results_date"},
+		)
+	}
+	if ok, err := columnExists(tx, "excluded_users", "guild_id"); err != nil {
+		return err
+	} else if !ok {
+		steps = append(steps,
+			step{query: "ALTER TABLE excluded_users RENAME TO excluded_users_old"},
+			step{query: createExcludedUsersSQL},
+			step{query: "INSERT INTO excluded_users (guild_id, user_id) SELECT ?, username FROM excluded_users_old", args: []any{defaultGuildID}},
+			step{query: "DROP TABLE excluded_users_old"},
+		)
+	}
+	if len(steps) == 0 {
+		return nil
+	}
+
+	if defaultGuildID == "" {
+		slog.Warn("DEFAULT_GUILD_ID is not set, existing scores will not be shown in any guild")
+	}
+	slog.Info("Migrating existing scores to guild", "guild", defaultGuildID)
+
+	for _, st := range steps {
+		if _, err := tx.Exec(st.query, st.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Rename the old username key columns to user_id. Mentions were already
+// stored as the bare ID, so existing values carry over unchanged, and the
+// leaderboard gets a separate username column used as a display cache.
+func migrateToUserIDs(tx *sql.Tx) error {
+	ok, err := columnExists(tx, "leaderboard", "user_id")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.Info("Migrating leaderboard to Discord user IDs")
+		steps := []string{
+			"ALTER TABLE leaderboard RENAME COLUMN username TO user_id",
+			"ALTER TABLE leaderboard ADD COLUMN username TEXT NOT NULL DEFAULT ''",
+			"UPDATE leaderboard SET username = user_id",
+		}
+		for _, query := range steps {
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+		}
+	}
+	for _, table := range []string{"daily_results", "excluded_users"} {
+		ok, err := columnExists(tx, table, "user_id")
+		if err != nil {
+			return err
+		}
+		if ok {
+			continue
+		}
+		if _, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s RENAME COLUMN username TO user_id", table)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Columns added after the tables were first created
+func addTrackingColumns(tx *sql.Tx) error {
+	columns := []struct{ table, column, definition string }{
+		{"leaderboard", "current_streak", "INTEGER NOT NULL DEFAULT 0"},
+		{"leaderboard", "max_streak", "INTEGER NOT NULL DEFAULT 0"},
+		{"leaderboard", "fails", "INTEGER NOT NULL DEFAULT 0"},
+		{"daily_results", "failed", "INTEGER NOT NULL DEFAULT 0"},
+		{"daily_results", "puzzle_number", "INTEGER NOT NULL DEFAULT 0"},
+		// SQLite can't add a column defaulting to CURRENT_TIMESTAMP, so older
+		// databases get a plain column (NULL for existing rows) and new rows
+		// set it explicitly in insertDailyResult
+		{"daily_results", "inserted_at", "TIMESTAMP"},
+	}
+	for _, c := range columns {
+		if err := addColumnIfMissing(tx, c.table, c.column, c.definition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Give every board-scoped table a channel_id, leaving existing rows on the
+// guild-wide board (channel_id ""). processed_days has it in its primary
+// key, so that table is rebuilt. initializeDatabase recreates the indexes.
+func migrateToChannels(tx *sql.Tx) error {
+	for _, table := range []string{"leaderboard", "daily_results", "adjustments"} {
+		if err := addColumnIfMissing(tx, table, "channel_id", "TEXT NOT NULL DEFAULT ''"); err != nil {
+			return err
+		}
+	}
+
+	ok, err := columnExists(tx, "processed_days", "channel_id")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		steps := []string{
+			"ALTER TABLE processed_days RENAME TO processed_days_old",
+			createProcessedDaysSQL,
+			"INSERT INTO processed_days (guild_id, puzzle_number, result_date, processed_at) SELECT guild_id, puzzle_number, result_date, processed_at FROM processed_days_old",
+			"DROP TABLE processed_days_old",
+		}
+		for _, query := range steps {
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Superseded by the channel-aware indexes
+	return dropIndexes(tx, "idx_leaderboard_user", "idx_daily_results_guild_date")
+}
+
+// Older versions could create a leaderboard row from a miss penalty alone,
+// leaving users with days_played = 0 who piled up penalties while hidden from
+// every standing. Remove them and their penalty days; rows an admin has
+// adjusted are left alone.
+func dropPenaltyOnlyUsers(tx *sql.Tx) error {
+	ghost := func(table string) string {
+		return `EXISTS (
+            SELECT 1 FROM leaderboard l
+            WHERE l.guild_id = ` + table + `.guild_id AND l.channel_id = ` + table + `.channel_id AND l.user_id = ` + table + `.user_id
+              AND l.days_played = 0 AND NOT EXISTS (
+                SELECT 1 FROM adjustments a
+                WHERE a.guild_id = l.guild_id AND a.channel_id = l.channel_id AND a.user_id = l.user_id))`
+	}
+	_, err := tx.Exec(`DELETE FROM daily_results WHERE played = 0 AND ` + ghost("daily_results"))
+	if err != nil {
+		return fmt.Errorf("deleting penalty-only results: %w", err)
+	}
+	res, err := tx.Exec(`DELETE FROM leaderboard WHERE ` + ghost("leaderboard"))
+	if err != nil {
+		return fmt.Errorf("deleting penalty-only users: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		slog.Info("Removed users who had only ever been penalized", "users", n)
+	}
+	return nil
+}
+
+// Entries keyed on a name rather than a Discord ID were stored as typed, so
+// "Alex" would no longer match the normalized "alex" that cleanUsername now
+// produces. Rename them to the normalized key, except where that key is
+// already taken on the board: those are logged to be combined with !merge.
+func normalizeNameKeys(tx *sql.Tx) error {
+	type entry struct{ guildID, channelID, userID string }
+	rows, err := tx.Query("SELECT guild_id, channel_id, user_id FROM leaderboard")
+	if err != nil {
+		return err
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.guildID, &e.channelID, &e.userID); err != nil {
+			rows.Close()
+			return err
+		}
+		if !isUserID(e.userID) && normalizeName(e.userID) != e.userID {
+			entries = append(entries, e)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		key := normalizeName(e.userID)
+		var taken int
+		err := tx.QueryRow("SELECT COUNT(*) FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND user_id = ?", e.guildID, e.channelID, key).Scan(&taken)
+		if err != nil {
+			return err
+		}
+		if taken > 0 {
+			slog.Warn("Legacy entries differ only by case or spacing, combine them with !merge", "guild", e.guildID, "channel", e.channelID, "user", e.userID, "normalized", key)
+			continue
+		}
+		for _, table := range []string{"leaderboard", "daily_results", "adjustments"} {
+			_, err := tx.Exec("UPDATE "+table+" SET user_id = ? WHERE guild_id = ? AND channel_id = ? AND user_id = ?", key, e.guildID, e.channelID, e.userID)
+			if err != nil {
+				return fmt.Errorf("normalizing %s in %s: %w", e.userID, table, err)
+			}
+		}
+	}
+
+	// Exclusions are per guild; a row whose normalized key is already
+	// excluded is left as it is, which is harmless
+	rows, err = tx.Query("SELECT guild_id, user_id FROM excluded_users")
+	if err != nil {
+		return err
+	}
+	var excluded []entry
+	for rows.Next() {
+		var e entry
+		if err := rows.Scan(&e.guildID, &e.userID); err != nil {
+			rows.Close()
+			return err
+		}
+		if !isUserID(e.userID) && normalizeName(e.userID) != e.userID {
+			excluded = append(excluded, e)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, e := range excluded {
+		key := normalizeName(e.userID)
+		_, err := tx.Exec("UPDATE excluded_users SET user_id = ? WHERE guild_id = ? AND user_id = ? AND NOT EXISTS (SELECT 1 FROM excluded_users x WHERE x.guild_id = ? AND x.user_id = ?)", key, e.guildID, e.userID, e.guildID, key)
+		if err != nil {
+			return fmt.Errorf("normalizing excluded user %s: %w", e.userID, err)
+		}
+	}
+	return nil
+}
+
+// Self-service opt-outs share excluded_users with admin exclusions
+func addOptOutColumn(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "excluded_users", "opted_out", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// Store the all-time rank each player had after the last processed day
+func addLastRankColumn(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "leaderboard", "last_rank", "INTEGER NOT NULL DEFAULT 0")
+}
+
+// Add daily_results.points and fill it in for past results with the default
+// points, which !reprocess can redo with a custom POINTS_MAP
+func addPointsColumn(tx *sql.Tx) error {
+	ok, err := columnExists(tx, "daily_results", "points")
+	if err != nil || ok {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "daily_results", "points", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	_, err = tx.Exec(`
+    UPDATE daily_results SET points = CASE
+        WHEN played = 0 OR failed = 1 THEN 0
+        WHEN score BETWEEN 1 AND 6 THEN 7 - score
+        ELSE 0 END`)
+	if err != nil {
+		return fmt.Errorf("filling in points: %w", err)
+	}
+	return nil
+}
+
+// Give the results tables a game column, leaving existing rows on Wordle.
+// processed_days has it in its primary key, so that table is rebuilt.
+// initializeDatabase recreates the indexes, now including the game.
+func migrateToGames(tx *sql.Tx) error {
+	for _, table := range []string{"leaderboard", "daily_results", "adjustments"} {
+		if err := addColumnIfMissing(tx, table, "game", "TEXT NOT NULL DEFAULT 'wordle'"); err != nil {
+			return err
+		}
+	}
+
+	ok, err := columnExists(tx, "processed_days", "game")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		steps := []string{
+			"ALTER TABLE processed_days RENAME TO processed_days_old",
+			createProcessedDaysSQL,
+			"INSERT INTO processed_days (guild_id, channel_id, puzzle_number, result_date, processed_at) SELECT guild_id, channel_id, puzzle_number, result_date, processed_at FROM processed_days_old",
+			"DROP TABLE processed_days_old",
+		}
+		for _, query := range steps {
+			if _, err := tx.Exec(query); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Superseded by the game-aware indexes
+	return dropIndexes(tx, "idx_leaderboard_board_user", "idx_daily_results_board_date")
+}
+
+// Add leaderboard.last_played and fill it in from the recorded results
+func addLastPlayedColumn(tx *sql.Tx) error {
+	ok, err := columnExists(tx, "leaderboard", "last_played")
+	if err != nil || ok {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "leaderboard", "last_played", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE leaderboard SET last_played = " + playedDateSQL("MAX")); err != nil {
+		return fmt.Errorf("filling in last played dates: %w", err)
+	}
+	return nil
+}
+
+// Add leaderboard.joined_date, the first day each player played, filled in
+// from the recorded results
+func addJoinedDateColumn(tx *sql.Tx) error {
+	ok, err := columnExists(tx, "leaderboard", "joined_date")
+	if err != nil || ok {
+		return err
+	}
+	if err := addColumnIfMissing(tx, "leaderboard", "joined_date", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if _, err := tx.Exec("UPDATE leaderboard SET joined_date = " + playedDateSQL("MIN")); err != nil {
+		return fmt.Errorf("filling in joined dates: %w", err)
+	}
+	return nil
+}
+
+// Everyone starts out active
+func addActiveColumn(tx *sql.Tx) error {
+	return addColumnIfMissing(tx, "leaderboard", "active", "INTEGER NOT NULL DEFAULT 1")
+}
+
+// Add a column to an existing table unless it is already there
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	ok, err := columnExists(tx, table, column)
+	if err != nil || ok {
+		return err
+	}
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	if err != nil {
+		return fmt.Errorf("adding column %s.%s: %w", table, column, err)
+	}
+	return nil
+}
+
+// Check whether a table has a given column, from the columns an empty
+// query returns so it works on every backend
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	rows, err := tx.Query("SELECT * FROM " + table + " LIMIT 0")
+	if err != nil {
+		return false, fmt.Errorf("inspecting %s schema: %w", table, err)
+	}
+	defer rows.Close()
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, fmt.Errorf("inspecting %s schema: %w", table, err)
+	}
+	return slices.Contains(columns, column), nil
+}
+
+// Drop indexes that newer ones replace, if they are still there
+func dropIndexes(tx *sql.Tx, names ...string) error {
+	for _, name := range names {
+		if _, err := tx.Exec("DROP INDEX IF EXISTS " + name); err != nil {
+			return fmt.Errorf("dropping index %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// Climbers and droppers listed by !movers, each
+const moversLimit = 5
+
+// The rank snapshot taken on a date, user ID -> rank
+func (b *Bot) rankSnapshot(bd board, date string) (map[string]int, error) {
+	rows, err := b.db.Query("SELECT user_id, rank FROM rank_snapshots WHERE guild_id = ? AND channel_id = ? AND snapshot_date = ?", bd.guildID, bd.channelID, date)
+	if err != nil {
+		return nil, fmt.Errorf("loading rank snapshot: %w", err)
+	}
+	defer rows.Close()
+
+	ranks := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var rank int
+		if err := rows.Scan(&userID, &rank); err != nil {
+			return nil, fmt.Errorf("scanning rank snapshot: %w", err)
+		}
+		ranks[userID] = rank
+	}
+	return ranks, rows.Err()
+}
+
+// The two most recent snapshot dates of a board, "" where there is none
+func (b *Bot) lastSnapshotDates(bd board) (latest, previous string, err error) {
+	var l, p sql.NullString
+	err = b.db.QueryRow(`
+    SELECT MAX(snapshot_date),
+           (SELECT MAX(snapshot_date) FROM rank_snapshots
+            WHERE guild_id = ? AND channel_id = ?
+              AND snapshot_date < (SELECT MAX(snapshot_date) FROM rank_snapshots WHERE guild_id = ? AND channel_id = ?))
+    FROM rank_snapshots WHERE guild_id = ? AND channel_id = ?`,
+		bd.guildID, bd.channelID, bd.guildID, bd.channelID, bd.guildID, bd.channelID).Scan(&l, &p)
+	return l.String, p.String, err
+}
+
+// Build the list of the biggest rank climbers and droppers between the two
+// most recent snapshots. Players without an earlier snapshot are listed as
+// new entries rather than as movers.
+func (b *Bot) buildMovers(bd board) (string, error) {
+	latest, previous, err := b.lastSnapshotDates(bd)
+	if err != nil {
+		return "", fmt.Errorf("finding snapshot dates: %w", err)
+	}
+	if previous == "" {
+		return "No rank changes to compare yet, check back after the next processed day!", nil
+	}
+	now, err := b.rankSnapshot(bd, latest)
+	if err != nil {
+		return "", err
+	}
+	before, err := b.rankSnapshot(bd, previous)
+	if err != nil {
+		return "", err
+	}
+
+	var climbers, droppers []rankChange
+	var newcomers []string
+	for userID, rank := range now {
+		from, ok := before[userID]
+		switch {
+		case !ok:
+			newcomers = append(newcomers, userID)
+		case from > rank:
+			climbers = append(climbers, rankChange{userID: userID, from: from, to: rank})
+		case from < rank:
+			droppers = append(droppers, rankChange{userID: userID, from: from, to: rank})
+		}
+	}
+	// Biggest moves first, then by current rank
+	byMove := func(changes []rankChange) {
+		sort.Slice(changes, func(i, j int) bool {
+			mi, mj := abs(changes[i].places()), abs(changes[j].places())
+			if mi != mj {
+				return mi > mj
+			}
+			if changes[i].to != changes[j].to {
+				return changes[i].to < changes[j].to
+			}
+			return changes[i].userID < changes[j].userID
+		})
+	}
+	byMove(climbers)
+	byMove(droppers)
+	sort.Slice(newcomers, func(i, j int) bool {
+		if now[newcomers[i]] != now[newcomers[j]] {
+			return now[newcomers[i]] < now[newcomers[j]]
+		}
+		return newcomers[i] < newcomers[j]
+	})
+
+	output := fmt.Sprintf("📊 **Biggest Movers since %s** 📊\n", previous)
+	for _, c := range climbers[:min(len(climbers), moversLimit)] {
+		output += fmt.Sprintf("↑%d %s - now #%d (was #%d)\n", c.places(), mention(c.userID), c.to, c.from)
+	}
+	for _, c := range droppers[:min(len(droppers), moversLimit)] {
+		output += fmt.Sprintf("↓%d %s - now #%d (was #%d)\n", -c.places(), mention(c.userID), c.to, c.from)
+	}
+	for _, userID := range newcomers[:min(len(newcomers), moversLimit)] {
+		output += fmt.Sprintf("🆕 %s - new at #%d\n", mention(userID), now[userID])
+	}
+	if len(climbers)+len(droppers)+len(newcomers) == 0 {
+		output += "Nobody moved!"
+	}
+	return output, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Characters Discord sometimes leaves in names that render as nothing, such
+// as a trailing zero-width space
+var invisibleRunes = map[rune]bool{
+	'\u200B': true, // zero-width space
+	'\u200C': true, // zero-width non-joiner
+	'\u200D': true, // zero-width joiner
+	'\u2060': true, // word joiner
+	'\uFEFF': true, // zero-width no-break space / BOM
+}
+
+// Tidy a name for display: trim it, drop invisible characters and put it
+// in Unicode NFC, so "e" + U+0301 and the precomposed "é" (or any other
+// letter typed two ways) compare and look the same
+func cleanDisplayName(name string) string {
+	visible := strings.Map(func(r rune) rune {
+		if invisibleRunes[r] {
+			return -1
+		}
+		return r
+	}, name)
+	return strings.TrimFunc(norm.NFC.String(visible), unicode.IsSpace)
+}
+
+// Normalize a name used as a key, so "Alex", "alex" and "Alex\u200B" are one
+// user: cleanDisplayName, then lowercased. Numeric Discord IDs pass through.
+func normalizeName(name string) string {
+	return strings.ToLower(cleanDisplayName(name))
+}
+
+// Report whether a key is a Discord user ID rather than a legacy or bare name
+func isUserID(key string) bool {
+	_, err := strconv.ParseUint(key, 10, 64)
+	return err == nil
+}
+
+// Markdown characters a name could use to format the line it is shown on
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`)
+
+// A name made safe to show as plain text in a message or embed
+func escapeMarkdown(name string) string {
+	return markdownEscaper.Replace(name)
+}
+
+// How to name a player in a message: a mention for a Discord user ID, the
+// key itself as plain text for a legacy or bare name entry, which Discord
+// would otherwise show as a broken "<@name>"
+func mention(userID string) string {
+	if isUserID(userID) {
+		return "<@" + userID + ">"
+	}
+	return escapeMarkdown(userID)
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+func TestNormalizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"lowercased", "Alex", "alex"},
+		{"trimmed", "  alex ", "alex"},
+		{"trailing zero-width space", "alex\u200B", "alex"},
+		{"zero-width characters inside", "al\u200Dex\uFEFF", "alex"},
+		{"precomposed accent", "Zoë", "zoë"},
+		{"combining accent composed", "Zoe\u0308", "zoë"},
+		{"combining accent and zero-width space", "Rene\u0301\u200B", "rené"},
+		{"accent outside Latin-1", "Ngo\u0323c", "ngọc"},
+		{"several combining marks", "a\u0323\u0302", "ậ"},
+		{"marks in either order", "a\u0302\u0323", "ậ"},
+		{"non-Latin script", "カ\u3099", "ガ"},
+		{"user ID unchanged", "123456789012345678", "123456789012345678"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeName(tt.in); got != tt.want {
+				t.Errorf("normalizeName(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCleanDisplayNameKeepsCase(t *testing.T) {
+	if got := cleanDisplayName("Zoe\u0308\u200B"); got != "Zoë" {
+		t.Errorf("cleanDisplayName = %q, want %q", got, "Zoë")
+	}
+}
+
+func TestNormalizedNamesMatch(t *testing.T) {
+	for _, pair := range [][2]string{
+		{"Alex", "alex\u200B"},
+		{"René", "RENE\u0301"},
+		{"Zoë", "zoe\u0308 "},
+	} {
+		if a, b := normalizeName(pair[0]), normalizeName(pair[1]); a != b {
+			t.Errorf("%q and %q normalize to %q and %q, want the same key", pair[0], pair[1], a, b)
+		}
+	}
+}
+
+func TestMention(t *testing.T) {
+	tests := []struct {
+		userID string
+		want   string
+	}{
+		{"123456789012345678", "<@123456789012345678>"},
+		{"111", "<@111>"},
+		{"alex", "alex"},
+		{"zoë", "zoë"},
+		{"cool_guy*", `cool\_guy\*`},
+		{"<@alex>", `<@alex\>`},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := mention(tt.userID); got != tt.want {
+			t.Errorf("mention(%q) = %q, want %q", tt.userID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Reactions that page a leaderboard message back and forward
+const (
+	pageBackEmoji    = "◀️"
+	pageForwardEmoji = "▶️"
+)
+
+// How long a paged leaderboard keeps responding to its reactions
+const pagerTTL = time.Hour
+
+// Paging state of one posted leaderboard message
+type leaderboardPager struct {
+	bd      board
+	period  leaderboardPeriod
+	page    int
+	expires time.Time
+}
+
+// Paged leaderboard messages by message ID
+type pagers struct {
+	mu   sync.Mutex
+	byID map[string]*leaderboardPager
+}
+
+// Remember a paged message, dropping expired ones while at it
+func (p *pagers) add(messageID string, pager *leaderboardPager) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.byID == nil {
+		p.byID = make(map[string]*leaderboardPager)
+	}
+	now := time.Now()
+	for id, old := range p.byID {
+		if now.After(old.expires) {
+			delete(p.byID, id)
+		}
+	}
+	p.byID[messageID] = pager
+}
+
+// Stop paging a message, e.g. because it was edited into something else
+func (p *pagers) remove(messageID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.byID, messageID)
+}
+
+// Move a paged message by delta pages, clamped to 1..pages. Returns the new
+// page, or 0 if the message isn't paged (any more) or the page didn't change.
+func (p *pagers) turn(messageID string, delta, pages int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pager := p.byID[messageID]
+	if pager == nil || time.Now().After(pager.expires) {
+		return 0
+	}
+	page := min(max(pager.page+delta, 1), pages)
+	if page == pager.page {
+		return 0
+	}
+	pager.page = page
+	return page
+}
+
+// Look up the paging state of a message, nil if it isn't paged. Only turn
+// changes the page, under the lock.
+func (p *pagers) get(messageID string) *leaderboardPager {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pager := p.byID[messageID]
+	if pager == nil || time.Now().After(pager.expires) {
+		return nil
+	}
+	return pager
+}
+
+// Post page 1 of a leaderboard with reactions for paging through the rest
+func (b *Bot) sendPagedLeaderboard(s *discordgo.Session, channelID string, bd board, period leaderboardPeriod) {
+	embed, err := b.buildLeaderboardPage(bd, period, 1)
+	if err != nil {
+		slog.Error("Error fetching leaderboard", "err", err)
+		return
+	}
+	msg := b.postLeaderboard(s, channelID, bd, embed)
+	if msg == nil {
+		return
+	}
+
+	b.pagers.add(msg.ID, &leaderboardPager{bd: bd, period: period, page: 1, expires: time.Now().Add(pagerTTL)})
+	for _, emoji := range []string{pageBackEmoji, pageForwardEmoji} {
+		if err := s.MessageReactionAdd(channelID, msg.ID, emoji); err != nil {
+			slog.Error("Error adding paging reaction", "err", err)
+			return
+		}
+	}
+}
+
+// Turn the page of a paged leaderboard when someone clicks ◀️ or ▶️
+func (b *Bot) onMessageReactionAdd(s *discordgo.Session, r *discordgo.MessageReactionAdd) {
+	if r.UserID == s.State.User.ID {
+		return // The bot's own reactions added by sendPagedLeaderboard
+	}
+
+	var delta int
+	switch strings.TrimSuffix(r.Emoji.Name, "\ufe0f") {
+	case strings.TrimSuffix(pageBackEmoji, "\ufe0f"):
+		delta = -1
+	case strings.TrimSuffix(pageForwardEmoji, "\ufe0f"):
+		delta = 1
+	default:
+		return
+	}
+	pager := b.pagers.get(r.MessageID)
+	if pager == nil {
+		return
+	}
+
+	// Remove the click so the same arrow can be used again
+	if err := s.MessageReactionRemove(r.ChannelID, r.MessageID, r.Emoji.APIName(), r.UserID); err != nil {
+		slog.Debug("Error removing paging reaction", "err", err)
+	}
+
+	lines, err := b.leaderboardLines(pager.bd, pager.period)
+	if err != nil {
+		slog.Error("Error fetching leaderboard", "err", err)
+		return
+	}
+	pages := max((len(lines)+leaderboardPageSize-1)/leaderboardPageSize, 1)
+	page := b.pagers.turn(r.MessageID, delta, pages)
+	if page == 0 {
+		return
+	}
+
+	embed, err := b.buildLeaderboardPage(pager.bd, pager.period, page)
+	if err != nil {
+		slog.Error("Error fetching leaderboard", "err", err)
+		return
+	}
+	sendWithRetry(r.ChannelID, func() error {
+		_, err := s.ChannelMessageEditEmbed(r.ChannelID, r.MessageID, embed)
+		return err
+	})
+}
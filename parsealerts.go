@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Longest part of a raw message quoted in a parse failure alert, leaving
+// room under Discord's message limit
+const parseAlertContentLimit = 1500
+
+// Warn LOG_CHANNEL_ID that a Wordle results message yielded no scores at
+// all, quoting it, since that usually means its format changed and the
+// parser needs updating. Does nothing without a log channel.
+func (b *Bot) alertParseFailure(s *discordgo.Session, msg *discordgo.Message) {
+	if b.logChannelID == "" {
+		return
+	}
+	content := []rune(msg.Content)
+	truncated := len(content) > parseAlertContentLimit
+	if truncated {
+		content = content[:parseAlertContentLimit]
+	}
+
+	alert := fmt.Sprintf("⚠️ **No scores found in a results message** in <#%s>, the Wordle bot's format may have changed.\n", msg.ChannelID)
+	if msg.GuildID != "" {
+		alert += fmt.Sprintf("https://discord.com/channels/%s/%s/%s\n", msg.GuildID, msg.ChannelID, msg.ID)
+	}
+	// Backticks in the message would end the code block early
+	alert += "```\n" + strings.ReplaceAll(string(content), "`", "'") + "\n```"
+	if truncated {
+		alert += "(truncated)"
+	}
+	if err := sendMessage(s, b.logChannelID, alert); err != nil {
+		slog.Error("Error sending parse failure alert", "channel", b.logChannelID, "err", err)
+	}
+}
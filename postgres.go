@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// The Postgres backend, DB_DRIVER=postgres with DATABASE_URL
+type postgresStore struct {
+	sqlStore
+}
+
+// A store on an open Postgres database
+func newPostgresStore(db *sql.DB) *postgresStore {
+	return &postgresStore{sqlStore{db: db}}
+}
+
+// Connection pool limits for Postgres. Handlers query concurrently, and
+// unlike SQLite the server takes several writers at once, so the pool may
+// grow; a server with a low max_connections, or one behind a proxy that
+// drops idle connections, may want it kept smaller.
+type poolSettings struct {
+	maxOpen     int           // DB_MAX_OPEN_CONNS, 0 for no limit
+	maxIdle     int           // DB_MAX_IDLE_CONNS, kept open between queries
+	maxLifetime time.Duration // DB_CONN_MAX_LIFETIME, 0 to reuse connections forever
+}
+
+// database/sql's own defaults, kept unless the environment says otherwise
+var defaultPoolSettings = poolSettings{maxIdle: 2}
+
+// Read the pool settings, refusing negative or unreadable values
+func loadPoolSettings() (poolSettings, error) {
+	p := defaultPoolSettings
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"DB_MAX_OPEN_CONNS", &p.maxOpen},
+		{"DB_MAX_IDLE_CONNS", &p.maxIdle},
+	} {
+		raw := strings.TrimSpace(os.Getenv(setting.name))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			return p, fmt.Errorf("%s must be a whole number of connections, got %q", setting.name, raw)
+		}
+		*setting.value = n
+	}
+	if raw := strings.TrimSpace(os.Getenv("DB_CONN_MAX_LIFETIME")); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if raw == "0" {
+			d, err = 0, nil
+		}
+		if err != nil || d < 0 {
+			return p, fmt.Errorf("DB_CONN_MAX_LIFETIME must be a duration like 30m, got %q", raw)
+		}
+		p.maxLifetime = d
+	}
+	return p, nil
+}
+
+// Whether any of the pool settings is set, which only Postgres reads
+func poolSettingsSet() bool {
+	for _, name := range []string{"DB_MAX_OPEN_CONNS", "DB_MAX_IDLE_CONNS", "DB_CONN_MAX_LIFETIME"} {
+		if strings.TrimSpace(os.Getenv(name)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Connect to the Postgres database at url, a postgres:// URL or key=value
+// connection string, with the given pool. Pings it so a wrong address or
+// password stops the bot at startup rather than on the first results
+// message.
+func openPostgres(url string, pool poolSettings) (*sql.DB, error) {
+	config, err := pgx.ParseConfig(url)
+	if err != nil {
+		return nil, err
+	}
+	db := sql.OpenDB(rebindConnector{stdlib.GetConnector(*config)})
+	db.SetMaxOpenConns(pool.maxOpen)
+	db.SetMaxIdleConns(pool.maxIdle)
+	db.SetConnMaxLifetime(pool.maxLifetime)
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Auto-incrementing IDs are the one part of the schema Postgres spells
+// differently
+func (s *postgresStore) Schema(create string) string {
+	return strings.ReplaceAll(create, "INTEGER PRIMARY KEY AUTOINCREMENT", "SERIAL PRIMARY KEY")
+}
+
+// Size of the current database on the server
+func (s *postgresStore) Size() (int64, error) {
+	var size int64
+	if err := s.db.QueryRow("SELECT pg_database_size(current_database())").Scan(&size); err != nil {
+		return 0, fmt.Errorf("reading database size: %w", err)
+	}
+	return size, nil
+}
+
+// Hands out connections that take the bot's SQLite style queries
+type rebindConnector struct {
+	driver.Connector
+}
+
+func (c rebindConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return rebindConn{conn.(*stdlib.Conn)}, nil
+}
+
+// A pgx connection running queries written with ? placeholders and 0/1
+// booleans, the way every query in the bot is written
+type rebindConn struct {
+	*stdlib.Conn
+}
+
+// Booleans are stored in INTEGER columns, which Postgres won't cast them to
+func (c rebindConn) CheckNamedValue(nv *driver.NamedValue) error {
+	v, err := driver.DefaultParameterConverter.ConvertValue(nv.Value)
+	if err != nil {
+		return err
+	}
+	if b, ok := v.(bool); ok {
+		v = int64(0)
+		if b {
+			v = int64(1)
+		}
+	}
+	nv.Value = v
+	return nil
+}
+
+func (c rebindConn) Prepare(query string) (driver.Stmt, error) {
+	return c.Conn.Prepare(rebind(query))
+}
+
+func (c rebindConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.PrepareContext(ctx, rebind(query))
+}
+
+func (c rebindConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.ExecContext(ctx, rebind(query), args)
+}
+
+func (c rebindConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return c.Conn.QueryContext(ctx, rebind(query), args)
+}
+
+// Number a query's ? placeholders $1, $2, ... as Postgres expects, leaving
+// question marks inside quoted strings and identifiers alone
+func rebind(query string) string {
+	if !strings.Contains(query, "?") {
+		return query
+	}
+	var out strings.Builder
+	var quote rune
+	n := 0
+	for _, r := range query {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?':
+			n++
+			out.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		out.WriteRune(r)
+	}
+	return out.String()
+}
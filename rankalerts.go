@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Most rank changes announced after a day, biggest moves first
+const rankAlertLimit = 3
+
+// A player's move on the all-time leaderboard since the last processed day
+type rankChange struct {
+	userID   string
+	from, to int
+}
+
+// Places moved, positive when climbing
+func (c rankChange) places() int {
+	return c.from - c.to
+}
+
+// Save every player's all-time rank, also as today's rank snapshot for
+// !movers, and return how the players who opted in to rank alerts moved
+// since the ranks were last saved. Players without a saved rank (new, or
+// from before alerts existed) aren't reported.
+func (b *Bot) updateRanks(bd board) ([]rankChange, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	entries, err := b.leaderboardEntries(bd, allTimePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("loading standings: %w", err)
+	}
+	lastRanks, err := b.lastRanks(bd)
+	if err != nil {
+		return nil, err
+	}
+	alerted, err := b.rankAlertUsers(bd.guildID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Replace today's snapshot, which a later run the same day supersedes
+	today := b.now().Format(dateLayout)
+	_, err = tx.Exec("DELETE FROM rank_snapshots WHERE guild_id = ? AND channel_id = ? AND snapshot_date = ?", bd.guildID, bd.channelID, today)
+	if err != nil {
+		return nil, fmt.Errorf("clearing rank snapshot: %w", err)
+	}
+
+	var changes []rankChange
+	for i, rank := range competitionRanks(entries) {
+		userID := entries[i].userID
+		_, err := tx.Exec("INSERT INTO rank_snapshots (guild_id, channel_id, snapshot_date, user_id, rank) VALUES (?, ?, ?, ?, ?)", bd.guildID, bd.channelID, today, userID, rank)
+		if err != nil {
+			return nil, fmt.Errorf("saving rank snapshot of %s: %w", userID, err)
+		}
+		if from := lastRanks[userID]; alerted[userID] && from > 0 && from != rank {
+			changes = append(changes, rankChange{userID: userID, from: from, to: rank})
+		}
+		if lastRanks[userID] == rank {
+			continue
+		}
+		_, err = tx.Exec("UPDATE leaderboard SET last_rank = ? WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", rank, bd.guildID, bd.channelID, bd.game, userID)
+		if err != nil {
+			return nil, fmt.Errorf("saving rank of %s: %w", userID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("committing ranks: %w", err)
+	}
+	return changes, nil
+}
+
+// The rank saved for each player on a board after the last processed day
+func (b *Bot) lastRanks(bd board) (map[string]int, error) {
+	rows, err := b.db.Query("SELECT user_id, last_rank FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ?", bd.guildID, bd.channelID, bd.game)
+	if err != nil {
+		return nil, fmt.Errorf("loading saved ranks: %w", err)
+	}
+	defer rows.Close()
+
+	ranks := make(map[string]int)
+	for rows.Next() {
+		var userID string
+		var rank int
+		if err := rows.Scan(&userID, &rank); err != nil {
+			return nil, fmt.Errorf("scanning saved rank: %w", err)
+		}
+		ranks[userID] = rank
+	}
+	return ranks, rows.Err()
+}
+
+// Users in a guild who opted in to rank alerts
+func (b *Bot) rankAlertUsers(guildID string) (map[string]bool, error) {
+	rows, err := b.db.Query("SELECT user_id FROM rank_alerts WHERE guild_id = ?", guildID)
+	if err != nil {
+		return nil, fmt.Errorf("loading rank alert users: %w", err)
+	}
+	defer rows.Close()
+
+	users := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("scanning rank alert user: %w", err)
+		}
+		users[userID] = true
+	}
+	return users, rows.Err()
+}
+
+// One message pinging the players with the biggest rank changes, or "" if
+// nobody who opted in moved
+func formatRankChanges(changes []rankChange) string {
+	sort.Slice(changes, func(i, j int) bool {
+		mi, mj := abs(changes[i].places()), abs(changes[j].places())
+		if mi != mj {
+			return mi > mj
+		}
+		return changes[i].to < changes[j].to
+	})
+	changes = changes[:min(len(changes), rankAlertLimit)]
+
+	lines := make([]string, len(changes))
+	for i, c := range changes {
+		places := "places"
+		if abs(c.places()) == 1 {
+			places = "place"
+		}
+		if c.places() > 0 {
+			lines[i] = fmt.Sprintf("📈 %s climbed %d %s to #%d", mention(c.userID), c.places(), places, c.to)
+		} else {
+			lines[i] = fmt.Sprintf("📉 %s dropped %d %s to #%d", mention(c.userID), -c.places(), places, c.to)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Save the day's ranks and ping the opted-in players who moved the most
+func (b *Bot) announceRankChanges(s *discordgo.Session, channelID string, bd board) {
+	changes, err := b.updateRanks(bd)
+	if err != nil {
+		slog.Error("Error updating ranks", "err", err)
+		return
+	}
+	if msg := formatRankChanges(changes); msg != "" {
+		sendMessage(s, channelID, msg)
+	}
+}
+
+// Handle "!rankalerts [on|off]": opt in to or out of being pinged when your
+// all-time rank changes after a day is processed
+func (b *Bot) handleRankAlertsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	args := commandArgs(m.Content)
+	if len(args) == 0 {
+		alerted, err := b.rankAlertUsers(m.GuildID)
+		if err != nil {
+			slog.Error("Error loading rank alert users", "err", err)
+			return
+		}
+		state := "off"
+		if alerted[m.Author.ID] {
+			state = "on"
+		}
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Rank alerts are %s for you. Use `%srankalerts on` or `%[2]srankalerts off` to change that.", state, b.prefix))
+		return
+	}
+
+	var err error
+	var reply string
+	b.writeMu.Lock()
+	switch strings.ToLower(args[0]) {
+	case "on":
+		_, err = b.db.Exec("INSERT INTO rank_alerts (guild_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING", m.GuildID, m.Author.ID)
+		reply = "You'll be pinged when a day moves you up or down the leaderboard."
+	case "off":
+		_, err = b.db.Exec("DELETE FROM rank_alerts WHERE guild_id = ? AND user_id = ?", m.GuildID, m.Author.ID)
+		reply = "You won't be pinged about rank changes anymore."
+	default:
+		b.writeMu.Unlock()
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%srankalerts [on|off]`", b.prefix))
+		return
+	}
+	b.writeMu.Unlock()
+	if err != nil {
+		slog.Error("Error updating rank alerts", "err", err)
+		sendMessage(s, m.ChannelID, "Something went wrong, please try again later.")
+		return
+	}
+	sendMessage(s, m.ChannelID, reply)
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A prefix command, as dispatched by onMessageCreate and listed by !help
+type command struct {
+	name        string // matched after the prefix, e.g. "leaderboard"
+	args        string // argument syntax shown in help, e.g. "[week|month]"
+	description string
+	example     string // arguments of a usage example, empty if args says it all
+	adminOnly   bool   // noted in help and lifts the cooldown for admins; the handler checks permission
+	handler     func(b *Bot, s *discordgo.Session, m *discordgo.MessageCreate)
+}
+
+// Every prefix command the bot responds to, in the order !help lists them.
+// A message's first word must match a name (or an alias) exactly.
+// Filled in by init because the help handler reads this list itself.
+var commands []command
+
+func init() {
+	commands = []command{
+		{
+			name:        "leaderboard",
+			args:        "[game] [week|month|on YYYY-MM-DD] [page N] [sort=total|average|wins|streak]",
+			description: "Show the leaderboard, all time, for the last 7 days / this month or as it stood on a past date, ranked by average score (default), total points, days solved or current streak. Wordle unless another game is named.",
+			example:     "week",
+			handler:     (*Bot).handleLeaderboardCommand,
+		},
+		{
+			name:        "wins",
+			args:        "[game] [week|month|on YYYY-MM-DD] [page N]",
+			description: "Rank players by how many days they solved the puzzle (1-6, not X), fewer games breaking ties.",
+			example:     "month",
+			handler:     (*Bot).handleWinsCommand,
+		},
+		{
+			name:        "streaks",
+			args:        "[game]",
+			description: "Show the longest current daily streaks.",
+			handler:     (*Bot).handleStreaksCommand,
+		},
+		{
+			name:        "stats",
+			args:        "@user",
+			description: "Show a player's average, rank, best and worst scores.",
+			handler:     (*Bot).handleStatsCommand,
+		},
+		{
+			name:        "mystats",
+			description: "Show your own stats.",
+			handler:     (*Bot).handleMyStatsCommand,
+		},
+		{
+			name:        "rank",
+			args:        "[@user]",
+			description: "Show where you, or another player, rank on the all-time leaderboard.",
+			handler:     (*Bot).handleRankCommand,
+		},
+		{
+			name:        "compare",
+			args:        "@player [@player]",
+			description: "Compare two players, or yourself and another player, side by side with their head-to-head record.",
+			example:     "@alice @bob",
+			handler:     (*Bot).handleCompareCommand,
+		},
+		{
+			name:        "movers",
+			description: "Show who climbed or fell the most on the all-time leaderboard since the previous day.",
+			handler:     (*Bot).handleMoversCommand,
+		},
+		{
+			name:        "history",
+			args:        "[@user] [days]",
+			description: fmt.Sprintf("Show a sparkline of recent scores, the last %d days unless another count is given.", defaultHistoryDays),
+			example:     "@user 30",
+			handler:     (*Bot).handleHistoryCommand,
+		},
+		{
+			name:        "distribution",
+			args:        "[@user]",
+			description: "Show how often you, or another player, solved in 1-6 guesses or failed.",
+			handler:     (*Bot).handleDistributionCommand,
+		},
+		{
+			name:        "serverstats",
+			description: "Show server-wide trivia: puzzles and games tracked, the best day and the most active player.",
+			handler:     (*Bot).handleServerStatsCommand,
+		},
+		{
+			name:        "today",
+			description: "Show today's recorded results and who didn't submit.",
+			handler:     (*Bot).handleTodayCommand,
+		},
+		{
+			name:        "pending",
+			description: "Ping the players who haven't got a result in today yet.",
+			handler:     (*Bot).handlePendingCommand,
+		},
+		{
+			name:        "puzzle",
+			args:        "<number>",
+			description: "Show how everyone did on one Wordle puzzle, best to worst.",
+			example:     "1203",
+			handler:     (*Bot).handlePuzzleCommand,
+		},
+		{
+			name:        "seasons",
+			description: "List the finished seasons and their champions.",
+			handler:     (*Bot).handleSeasonsCommand,
+		},
+		{
+			name:        "season",
+			args:        "<name or number>",
+			description: "Show the final standings of a finished season.",
+			example:     "1",
+			handler:     (*Bot).handleSeasonCommand,
+		},
+		{
+			name:        "optout",
+			description: "Stop being scored and penalized. Your history is kept.",
+			handler:     (*Bot).handleOptOutCommand,
+		},
+		{
+			name:        "optin",
+			description: "Undo an optout and get your history back on the leaderboard.",
+			handler:     (*Bot).handleOptOutCommand,
+		},
+		{
+			name:        "vacation",
+			args:        "[@user] <days>",
+			description: "Go away without miss penalties or losing your streak, starting today; 0 ends it early. Admins can send anyone on vacation.",
+			example:     "7",
+			handler:     (*Bot).handleVacationCommand,
+		},
+		{
+			name:        "rankalerts",
+			args:        "[on|off]",
+			description: "Get pinged when a processed day moves you up or down the all-time leaderboard.",
+			handler:     (*Bot).handleRankAlertsCommand,
+		},
+		{
+			name:        "reminders",
+			args:        "[on|off]",
+			description: "Turn the daily ping for players who haven't played yet off or back on for yourself.",
+			handler:     (*Bot).handleRemindersCommand,
+		},
+		{
+			name:        "exclude",
+			args:        "@user",
+			description: "Hide a user from the leaderboard and stop recording their scores.",
+			adminOnly:   true,
+			handler:     (*Bot).handleExclusionCommand,
+		},
+		{
+			name:        "include",
+			args:        "@user",
+			description: "Undo an exclude.",
+			adminOnly:   true,
+			handler:     (*Bot).handleExclusionCommand,
+		},
+		{
+			name:        "revive",
+			args:        "@user",
+			description: "Put a player who left the server and came back on the leaderboard again.",
+			adminOnly:   true,
+			handler:     (*Bot).handleReviveCommand,
+		},
+		{
+			name:        "adjust",
+			args:        "@user ±points [±days]",
+			description: "Correct a player's total score, and optionally their days played. Every change is logged.",
+			example:     "@user -2 +1",
+			adminOnly:   true,
+			handler:     (*Bot).handleAdjustCommand,
+		},
+		{
+			name:        "undo",
+			args:        "[confirm]",
+			description: "Revert the most recently processed results. Shows what would change until run with `confirm`.",
+			adminOnly:   true,
+			handler:     (*Bot).handleUndoCommand,
+		},
+		{
+			name:        "reset",
+			args:        "[confirm [season name]]",
+			description: "Archive the leaderboard as a finished season and start a new one. Shows what would happen until run with `confirm`.",
+			example:     "confirm Spring 2026",
+			adminOnly:   true,
+			handler:     (*Bot).handleResetCommand,
+		},
+		{
+			name:        "reprocess",
+			args:        "[confirm]",
+			description: "Rebuild this season's leaderboard by parsing the stored results messages again. Shows what would happen until run with `confirm`.",
+			adminOnly:   true,
+			handler:     (*Bot).handleReprocessCommand,
+		},
+		{
+			name:        "merge",
+			args:        "@from @to",
+			description: "Fold a duplicate entry into another user's, summing scores and days played.",
+			adminOnly:   true,
+			handler:     (*Bot).handleMergeCommand,
+		},
+		{
+			name:        "parse",
+			args:        "[> quoted results]",
+			description: "Reply to a results message (or quote one) to see the puzzle number and scores the parser extracts from it. Nothing is recorded.",
+			adminOnly:   true,
+			handler:     (*Bot).handleParseCommand,
+		},
+		{
+			name:        "gaps",
+			description: "List puzzle numbers missing between the first and last processed puzzle, so they can be backfilled.",
+			handler:     (*Bot).handleGapsCommand,
+		},
+		{
+			name:        "status",
+			description: "Check the bot is keeping up: puzzles processed, any missed, days recorded, database size and uptime.",
+			adminOnly:   true,
+			handler:     (*Bot).handleStatusCommand,
+		},
+		{
+			name:        "dupes",
+			description: "List entries whose names match ignoring case, which are likely the same person.",
+			adminOnly:   true,
+			handler:     (*Bot).handleDupesCommand,
+		},
+		{
+			name:        "export",
+			description: "Upload the all-time leaderboard as a CSV file.",
+			adminOnly:   true,
+			handler:     (*Bot).handleExportCommand,
+		},
+		{
+			name:        "help",
+			description: "Show this list.",
+			handler:     (*Bot).handleHelpCommand,
+		},
+	}
+}
+
+// Reply with an embed describing every registered command
+func (b *Bot) handleHelpCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	sendEmbed(s, m.ChannelID, b.helpEmbed())
+}
+
+// Build the !help embed from the command registry
+func (b *Bot) helpEmbed() *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  "📖 Wordle Leaderboard Commands",
+		Color:  b.theme.color,
+		Footer: &discordgo.MessageEmbedFooter{Text: "/leaderboard, /stats and /mystats work as slash commands too."},
+	}
+	for _, cmd := range commands {
+		usage := b.prefix + cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+
+		value := cmd.description
+		if cmd.adminOnly {
+			value += " *(admins only)*"
+		}
+		if aliases := b.aliasesOf(cmd.name); len(aliases) > 0 {
+			value += "\nAlso: `" + b.prefix + strings.Join(aliases, "`, `"+b.prefix) + "`"
+		}
+		if cmd.example != "" {
+			value += fmt.Sprintf("\nExample: `%s%s %s`", b.prefix, cmd.name, cmd.example)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "`" + usage + "`",
+			Value: value,
+		})
+	}
+	return embed
+}
+
+// The aliases that invoke a command, sorted
+func (b *Bot) aliasesOf(name string) []string {
+	var aliases []string
+	for alias, canonical := range b.aliases {
+		if canonical == name {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
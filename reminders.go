@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Parse REMINDER_TIME, a local time of day such as "21:00"
+func parseReminderTime(raw string) (hour, minute int, err error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(raw))
+	if err != nil {
+		return 0, 0, fmt.Errorf("REMINDER_TIME must look like 21:00, got %q", raw)
+	}
+	return t.Hour(), t.Minute(), nil
+}
+
+// Wait for the reminder time each day (in the bot's timezone) and ping the
+// players who haven't played yet
+func (b *Bot) scheduleReminders(channelID string, hour, minute int) {
+	for {
+		now := b.now()
+		next := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, b.loc)
+		if !next.After(now) {
+			next = time.Date(now.Year(), now.Month(), now.Day()+1, hour, minute, 0, 0, b.loc)
+		}
+		<-time.After(time.Until(next))
+
+		b.sendReminder(channelID)
+	}
+}
+
+// Ping the players on a channel's board who have no result today and
+// haven't turned reminders off
+func (b *Bot) sendReminder(channelID string) {
+	channel, err := b.session.Channel(channelID)
+	if err != nil {
+		slog.Error("Error looking up reminder channel", "err", err)
+		return
+	}
+	bd := b.boardFor(channel.GuildID, channelID)
+
+	userIDs, err := b.pendingPlayers(bd, b.now().Format(dateLayout))
+	if err != nil {
+		slog.Error("Error fetching pending players", "err", err)
+		return
+	}
+	optedOut, err := b.reminderOptOuts(bd.guildID)
+	if err != nil {
+		slog.Error("Error loading reminder opt-outs", "err", err)
+		return
+	}
+	var mentions []string
+	for _, userID := range userIDs {
+		if !optedOut[userID] {
+			mentions = append(mentions, mention(userID))
+		}
+	}
+	if len(mentions) == 0 {
+		return
+	}
+
+	slog.Info("Sending reminder", "guild", bd.guildID, "channel", channelID, "players", len(mentions))
+	sendMessage(b.session, channelID, fmt.Sprintf("⏰ Today's Wordle is still waiting for %s", strings.Join(mentions, " ")))
+}
+
+// Users in a guild who turned the daily reminder off
+func (b *Bot) reminderOptOuts(guildID string) (map[string]bool, error) {
+	rows, err := b.db.Query("SELECT user_id FROM reminder_optouts WHERE guild_id = ?", guildID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := make(map[string]bool)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		users[userID] = true
+	}
+	return users, rows.Err()
+}
+
+// Handle "!reminders [on|off]": stop or resume the daily reminder ping for
+// the caller. Reminders are on for everyone unless turned off.
+func (b *Bot) handleRemindersCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	args := commandArgs(m.Content)
+	if len(args) == 0 {
+		optedOut, err := b.reminderOptOuts(m.GuildID)
+		if err != nil {
+			slog.Error("Error loading reminder opt-outs", "err", err)
+			return
+		}
+		state := "on"
+		if optedOut[m.Author.ID] {
+			state = "off"
+		}
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Reminders are %s for you. Use `%sreminders on` or `%[2]sreminders off` to change that.", state, b.prefix))
+		return
+	}
+
+	var err error
+	var reply string
+	b.writeMu.Lock()
+	switch strings.ToLower(args[0]) {
+	case "off":
+		_, err = b.db.Exec("INSERT INTO reminder_optouts (guild_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING", m.GuildID, m.Author.ID)
+		reply = "You won't be reminded to play anymore."
+	case "on":
+		_, err = b.db.Exec("DELETE FROM reminder_optouts WHERE guild_id = ? AND user_id = ?", m.GuildID, m.Author.ID)
+		reply = "You'll be pinged if you haven't played by reminder time."
+	default:
+		b.writeMu.Unlock()
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%sreminders [on|off]`", b.prefix))
+		return
+	}
+	b.writeMu.Unlock()
+	if err != nil {
+		slog.Error("Error updating reminder opt-outs", "err", err)
+		sendMessage(s, m.ChannelID, "Something went wrong, please try again later.")
+		return
+	}
+	sendMessage(s, m.ChannelID, reply)
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Timestamp format SQLite's CURRENT_TIMESTAMP uses, in UTC
+const sqliteTimestampLayout = "2006-01-02 15:04:05"
+
+// Keep a copy of a Wordle results message so it can be parsed again with
+// !reprocess after a parser fix. Only the fields the parser reads are kept;
+// mentions are stored as user ID -> display name. An edited message replaces
+// the stored content but keeps its original time.
+func (b *Bot) saveRawMessage(msg *discordgo.Message) {
+	mentions, err := json.Marshal(mentionNames(msg.Mentions))
+	if err != nil {
+		slog.Error("Error encoding message mentions", "err", err)
+		return
+	}
+	posted := msg.Timestamp
+	if posted.IsZero() {
+		posted = time.Now()
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	_, err = b.db.Exec(`
+    INSERT INTO raw_messages (message_id, guild_id, channel_id, author_id, content, mentions, posted_at) VALUES (?, ?, ?, ?, ?, ?, ?)
+    ON CONFLICT (message_id) DO UPDATE SET content = excluded.content, mentions = excluded.mentions`,
+		msg.ID, msg.GuildID, msg.ChannelID, msg.Author.ID, msg.Content, string(mentions), posted.UTC().Format(sqliteTimestampLayout))
+	if err != nil {
+		slog.Error("Error saving raw results message", "err", err)
+	}
+}
+
+// When the board's current season started, "" if it never had a reset.
+// Compare it with afterSQL.
+func (b *Bot) seasonStart(bd board) (string, error) {
+	var since string
+	err := b.db.QueryRow("SELECT COALESCE(MAX(CAST(ended_at AS TEXT)), '') FROM seasons WHERE guild_id = ? AND channel_id = ?", bd.guildID, bd.channelID).Scan(&since)
+	return since, err
+}
+
+// A condition on a timestamp column taking the season start twice: true
+// for every row when it is "", else for rows after it. Compared as text,
+// the way SQLite stores timestamps, since Postgres can't read "" as one.
+func afterSQL(column string) string {
+	return "(? = '' OR CAST(" + column + " AS TEXT) > ?)"
+}
+
+// The stored results messages of a board's current season, oldest first,
+// rebuilt as Discord messages
+func (b *Bot) rawMessages(bd board, since string) ([]*discordgo.Message, error) {
+	rows, err := b.db.Query(`
+    SELECT message_id, guild_id, channel_id, author_id, content, mentions, posted_at
+    FROM raw_messages
+    WHERE guild_id = ? AND (? = '' OR channel_id = ?) AND `+afterSQL("received_at")+`
+    ORDER BY posted_at, message_id`, bd.guildID, bd.channelID, bd.channelID, since, since)
+	if err != nil {
+		return nil, fmt.Errorf("loading raw messages: %w", err)
+	}
+	defer rows.Close()
+
+	var msgs []*discordgo.Message
+	for rows.Next() {
+		msg := &discordgo.Message{Author: &discordgo.User{}}
+		var mentions string
+		if err := rows.Scan(&msg.ID, &msg.GuildID, &msg.ChannelID, &msg.Author.ID, &msg.Content, &mentions, &msg.Timestamp); err != nil {
+			return nil, fmt.Errorf("scanning raw message: %w", err)
+		}
+		var names map[string]string
+		if err := json.Unmarshal([]byte(mentions), &names); err != nil {
+			return nil, fmt.Errorf("reading mentions of message %s: %w", msg.ID, err)
+		}
+		for userID, name := range names {
+			msg.Mentions = append(msg.Mentions, &discordgo.User{ID: userID, GlobalName: name})
+		}
+		msgs = append(msgs, msg)
+	}
+	return msgs, rows.Err()
+}
+
+// A day to record again during !reprocess
+type replayDay struct {
+	day    resultDay
+	scores map[string]int
+	names  map[string]string
+}
+
+// Work out the days the board's current season is rebuilt from: every stored
+// message parsed again, plus days recorded without one (imported, or from
+// before messages were stored) taken from their daily_results rows
+func (b *Bot) replayDays(s *discordgo.Session, bd board, since string) ([]replayDay, error) {
+	msgs, err := b.rawMessages(bd, since)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []replayDay
+	seen := make(map[resultDay]bool)
+	covered := make(map[string]bool) // dates with a stored message
+	for _, msg := range msgs {
+		results, err := b.parseResultsMessage(s, msg)
+		if errors.Is(err, errNoScores) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		covered[results.day.date] = true
+		if seen[results.day] || (results.day.puzzle > 0 && seen[resultDay{puzzle: results.day.puzzle}]) {
+			continue // A replay of a day already recorded, like alreadyProcessed
+		}
+		seen[results.day] = true
+		if results.day.puzzle > 0 {
+			seen[resultDay{puzzle: results.day.puzzle}] = true
+		}
+		days = append(days, replayDay{day: results.day, scores: results.scores, names: results.names})
+	}
+
+	// Played rows of dates no stored message covers; fails are stored as
+	// the fail penalty, so turn them back into failScore
+	rows, err := b.db.Query(`
+    SELECT result_date, puzzle_number, user_id, score, failed
+    FROM daily_results
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND played = 1 AND `+afterSQL("inserted_at")+`
+    ORDER BY result_date, id`, bd.guildID, bd.channelID, bd.game, since, since)
+	if err != nil {
+		return nil, fmt.Errorf("loading recorded results: %w", err)
+	}
+	defer rows.Close()
+	byDay := make(map[resultDay]map[string]int)
+	for rows.Next() {
+		var day resultDay
+		var userID string
+		var score, failed int
+		if err := rows.Scan(&day.date, &day.puzzle, &userID, &score, &failed); err != nil {
+			return nil, fmt.Errorf("scanning recorded result: %w", err)
+		}
+		if covered[day.date] {
+			continue
+		}
+		if failed == 1 {
+			score = failScore
+		}
+		if byDay[day] == nil {
+			byDay[day] = make(map[string]int)
+			days = append(days, replayDay{day: day})
+		}
+		byDay[day][userID] = score
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range days {
+		if days[i].scores == nil {
+			days[i].scores = byDay[days[i].day]
+		}
+	}
+
+	slices.SortStableFunc(days, func(x, y replayDay) int {
+		return strings.Compare(x.day.date, y.day.date)
+	})
+	return days, nil
+}
+
+// Rebuild a board's current season from scratch: clear its leaderboard,
+// daily results and processed days, record every replay day again in date
+// order, then reapply the !adjust corrections. Players who left the server
+// stay inactive. All in one transaction. Returns the number of days recorded.
+func (b *Bot) reprocess(s *discordgo.Session, bd board) (int, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	since, err := b.seasonStart(bd)
+	if err != nil {
+		return 0, fmt.Errorf("finding season start: %w", err)
+	}
+	days, err := b.replayDays(s, bd, since)
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("starting transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	inactive, err := inactiveUsers(tx, bd)
+	if err != nil {
+		return 0, err
+	}
+	for _, query := range []string{
+		"DELETE FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ?",
+		"DELETE FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND " + afterSQL("inserted_at"),
+		"DELETE FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND " + afterSQL("processed_at"),
+	} {
+		args := []any{bd.guildID, bd.channelID, bd.game}
+		if strings.Count(query, "?") > 3 {
+			args = append(args, since, since)
+		}
+		if _, err := tx.Exec(query, args...); err != nil {
+			return 0, fmt.Errorf("clearing derived results: %w", err)
+		}
+	}
+
+	for _, d := range days {
+		if err := b.recordResults(tx, bd, d.scores, d.names, d.day); err != nil {
+			return 0, fmt.Errorf("recording %s: %w", d.day.date, err)
+		}
+	}
+	for _, userID := range inactive {
+		_, err := tx.Exec("UPDATE leaderboard SET active = 0 WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", bd.guildID, bd.channelID, bd.game, userID)
+		if err != nil {
+			return 0, fmt.Errorf("keeping %s inactive: %w", userID, err)
+		}
+	}
+
+	_, err = tx.Exec(`
+    UPDATE leaderboard SET
+      score = score + (SELECT CAST(COALESCE(SUM(a.score_delta), 0) AS INTEGER) FROM adjustments a
+                       WHERE a.guild_id = leaderboard.guild_id AND a.channel_id = leaderboard.channel_id AND a.game = leaderboard.game
+                         AND a.user_id = leaderboard.user_id AND `+afterSQL("a.created_at")+`),
+      days_played = days_played + (SELECT CAST(COALESCE(SUM(a.days_delta), 0) AS INTEGER) FROM adjustments a
+                       WHERE a.guild_id = leaderboard.guild_id AND a.channel_id = leaderboard.channel_id AND a.game = leaderboard.game
+                         AND a.user_id = leaderboard.user_id AND `+afterSQL("a.created_at")+`)
+    WHERE guild_id = ? AND channel_id = ? AND game = ?`, since, since, since, since, bd.guildID, bd.channelID, bd.game)
+	if err != nil {
+		return 0, fmt.Errorf("reapplying adjustments: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("committing reprocess: %w", err)
+	}
+	return len(days), nil
+}
+
+// Handle "!reprocess [confirm]": parse the stored results messages of the
+// current season again and rebuild the leaderboard from them
+func (b *Bot) handleReprocessCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can reprocess results.")
+		return
+	}
+	bd := b.boardFor(m.GuildID, m.ChannelID)
+
+	if args := commandArgs(m.Content); len(args) == 0 || !strings.EqualFold(args[0], "confirm") {
+		since, err := b.seasonStart(bd)
+		if err != nil {
+			slog.Error("Error finding season start", "err", err)
+			return
+		}
+		msgs, err := b.rawMessages(bd, since)
+		if err != nil {
+			slog.Error("Error loading raw messages", "err", err)
+			return
+		}
+		sendMessage(s, m.ChannelID, fmt.Sprintf("This will rebuild this season's leaderboard by parsing the %d stored results message(s) again. "+
+			"Days without a stored message keep their recorded results and `%sadjust` corrections are reapplied, but `%[2]smerge`s have to be redone. Run `%[2]sreprocess confirm` to go ahead.", len(msgs), b.prefix))
+		return
+	}
+
+	days, err := b.reprocess(s, bd)
+	if err != nil {
+		slog.Error("Error reprocessing results", "err", err)
+		sendMessage(s, m.ChannelID, "Something went wrong while reprocessing, nothing was changed.")
+		return
+	}
+	slog.Info("Reprocessed results", "guild", bd.guildID, "channel", bd.channelID, "days", days, "admin", m.Author.ID)
+	sendMessage(s, m.ChannelID, fmt.Sprintf("Rebuilt the leaderboard from %d day(s) of results.", days))
+}
@@ -0,0 +1,794 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Date format used for the result_date column in daily_results
+const dateLayout = "2006-01-02"
+
+// The puzzle a set of daily results belongs to
+type resultDay struct {
+	date   string // result_date the results are recorded under
+	puzzle int    // Wordle puzzle number, 0 if the message didn't include it
+}
+
+// Regex patterns for scores, usernames and the puzzle number
+var (
+	userRegex = regexp.MustCompile(`<@!?\d+>|@\S+`) // Matches "<@123>" mentions, or plain "@username"
+
+	// Matches "3/6", "X/6", "x/6", "?/6", "**4/6**" and "3/6:", capturing the
+	// 1-6, X, x or ?. The score must stand alone, so "12/6", "1.5/6" and
+	// "3/6/2026" don't match. See parseScore for what each one counts as.
+	scoreRegex = regexp.MustCompile(`(?:^|[^\w/.,])([1-6]|[Xx?])/6(?:[^\w/]|$)`)
+
+	// Matches "Wordle No. 1,234", "Wordle #1234", ... but not "Wordle 3/6"
+	puzzleRegex = regexp.MustCompile(`(?i)\bWordle\s+(?:No\.?\s*|#)?(\d{1,3}(?:,\d{3})+|\d+)(?:[^\d/,]|$)`)
+)
+
+// Score the parser reports for an X/6; only a failed puzzle can score above 6.
+// The points actually recorded for it come from penalties.fail.
+const failScore = 7
+
+// Score the parser reports for a "?/6", a result the Wordle bot couldn't
+// report. The player is neither scored nor penalized for the day.
+const unknownScore = 0
+
+// Points added for a failed puzzle and for a day without a result
+type penalties struct {
+	fail int // PENALTY_FAIL
+	miss int // PENALTY_MISS
+}
+
+// Read PENALTY_FAIL and PENALTY_MISS, each defaulting to 7 points
+func loadPenalties() (penalties, error) {
+	p := penalties{fail: 7, miss: 7}
+	for _, setting := range []struct {
+		name  string
+		value *int
+	}{
+		{"PENALTY_FAIL", &p.fail},
+		{"PENALTY_MISS", &p.miss},
+	} {
+		raw := strings.TrimSpace(os.Getenv(setting.name))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return p, fmt.Errorf("%s must be a positive integer, got %q", setting.name, raw)
+		}
+		*setting.value = n
+	}
+	return p, nil
+}
+
+// How many lines after a mention its score may appear on (grid lines not counted)
+const scoreLookahead = 3
+
+// One results message, parsed and ready to record
+type dailyResults struct {
+	bd     board
+	day    resultDay
+	scores map[string]int    // user ID -> score, excluded players already dropped
+	names  map[string]string // display names the scores were posted under
+	mode   string            // "mentions" or "bare names"
+}
+
+// Record a results message of a game in three stages: parse it, apply it to
+// the game's board in one transaction, then announce the outcome. A message
+// that can't be parsed stops before any results are written; Wordle messages
+// are always stored first for !reprocess.
+func (b *Bot) processResultsMessage(s *discordgo.Session, msg *discordgo.Message, g game) {
+	if g.name == defaultGame {
+		b.saveRawMessage(msg)
+	}
+	b.metrics.messages.Add(1)
+
+	results, err := g.parse(b, s, msg)
+	if err != nil {
+		if !errors.Is(err, errEveryoneExcluded) {
+			b.metrics.parseFailures.Add(1)
+		}
+		slog.Warn("Not recording results message", "game", g.name, "guild", msg.GuildID, "channel", msg.ChannelID, "message", msg.ID, "bare_name_fallback", b.bareNames, "err", err)
+		if g.name == defaultGame && err == errNoScores {
+			b.alertParseFailure(s, msg)
+		}
+		return
+	}
+	results.bd = results.bd.forGame(g.name)
+
+	if g.perPlayer {
+		recorded, err := b.applyShare(g, results)
+		if err != nil {
+			b.metrics.dbErrors.Add(1)
+			slog.Error("Error recording shared results", "game", g.name, "err", err)
+			sendMessage(s, msg.ChannelID, "Something went wrong while recording your result, nothing was saved. Please let an admin know!")
+			return
+		}
+		switch {
+		case !recorded:
+			sendMessage(s, msg.ChannelID, fmt.Sprintf("Your %s result was already recorded!", g.title))
+		case !b.noResultsAck:
+			sendMessage(s, msg.ChannelID, formatShare(g, results))
+		}
+		return
+	}
+
+	recorded, err := b.applyResults(results)
+	if err != nil {
+		b.metrics.dbErrors.Add(1)
+		slog.Error("Error processing daily results", "err", err)
+		sendMessage(s, msg.ChannelID, "Something went wrong while recording today's results, nothing was saved. Please let an admin know!")
+		return
+	}
+	if !recorded {
+		slog.Info("Results already processed", "game", g.name, "guild", results.bd.guildID, "channel", results.bd.channelID, "puzzle", results.day.puzzle, "date", results.day.date)
+		if results.day.puzzle > 0 {
+			sendMessage(s, msg.ChannelID, fmt.Sprintf("Results for %s %d were already recorded!", g.title, results.day.puzzle))
+		} else {
+			sendMessage(s, msg.ChannelID, "Today's results were already recorded!")
+		}
+		return
+	}
+
+	b.announceResults(s, msg.ChannelID, results)
+}
+
+// Parse stage: read the day and scores from a results message and drop
+// excluded and opted-out players, who are left out entirely, including from
+// the winners of the day. Reads but never writes. Returns errNoScores if no
+// scores were found, errEveryoneExcluded if no one is left to score.
+func (b *Bot) parseResultsMessage(s *discordgo.Session, msg *discordgo.Message) (dailyResults, error) {
+	results := dailyResults{
+		bd: b.boardFor(msg.GuildID, msg.ChannelID),
+		day: resultDay{
+			date:   b.puzzleDate(msg),
+			puzzle: parsePuzzleNumber(msg.Content),
+		},
+	}
+	results.scores, results.names, results.mode = b.extractScores(s, msg)
+	if len(results.scores) == 0 {
+		return results, errNoScores
+	}
+
+	// Flag scores the emoji grids posted with them don't back up. They are
+	// still recorded, an admin can look into it.
+	if b.gridCheck {
+		for _, mm := range checkGrids(msg.Content) {
+			slog.Warn("Score doesn't match its emoji grid", "guild", msg.GuildID, "channel", msg.ChannelID, "user", mm.userID, "score", scoreResult(mm.score), "grid", scoreResult(mm.guesses))
+		}
+	}
+
+	for userID := range results.scores {
+		if b.excludedUser(msg.GuildID, userID) {
+			slog.Debug("Ignoring result of excluded user", "guild", msg.GuildID, "user", userID)
+			delete(results.scores, userID)
+		}
+	}
+	if len(results.scores) == 0 {
+		return results, errEveryoneExcluded
+	}
+
+	slog.Info("Parsed daily Wordle results", "guild", results.bd.guildID, "channel", results.bd.channelID, "mode", results.mode, "users", len(results.scores))
+	slog.Debug("Parsed daily Wordle scores", "guild", results.bd.guildID, "channel", results.bd.channelID, "scores", results.scores)
+	return results, nil
+}
+
+// Apply stage: record parsed results unless their day was already recorded.
+// Reports whether they were recorded; nothing is saved if any user fails.
+func (b *Bot) applyResults(results dailyResults) (bool, error) {
+	// Hold the write lock from the duplicate check until the results are
+	// saved, so a message delivered twice at once is only scored once
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	// Skip replays and re-sent messages for a day that was already scored
+	if b.alreadyProcessed(results.bd, results.day) {
+		return false, nil
+	}
+	if err := b.updateScoresBasedOnResults(results.bd, results.scores, results.names, results.day); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Announce stage: acknowledge recorded results, congratulate the winners
+// (and name the wooden spoon if enabled), then post the updated leaderboard
+// and any rank changes, and pass on the winner role. Rank changes and the
+// role follow the Wordle board only. NO_RESULTS_ACK and
+// NO_RESULTS_LEADERBOARD leave out the acknowledgment and the leaderboard.
+func (b *Bot) announceResults(s *discordgo.Session, channelID string, results dailyResults) {
+	if !b.noResultsAck {
+		sendMessage(s, channelID, "Daily results successfully processed!")
+	}
+	if winners := winnersOfTheDay(results.scores); winners != "" {
+		sendMessage(s, channelID, winners)
+	}
+	if b.woodenSpoon {
+		if spoon := woodenSpoonOfTheDay(results.scores); spoon != "" {
+			sendMessage(s, channelID, spoon)
+		}
+	}
+	if !b.noResultsLeaderboard {
+		b.sendLeaderboard(s, channelID, results.bd, b.postedPeriod(allTimePeriod))
+	}
+	if results.bd.game == defaultGame {
+		b.announceRankChanges(s, channelID, results.bd)
+		b.updateWinnerRole(s, results.bd)
+	}
+}
+
+// Read the scores in a results message (user ID -> score) and the names they
+// were posted under. Scores are matched to mentions, or to bare display names
+// when there are none and BARE_NAME_FALLBACK is on; mode says which.
+func (b *Bot) extractScores(s *discordgo.Session, msg *discordgo.Message) (map[string]int, map[string]string, string) {
+	_, dailyUsers, err := parseResults(msg.Content)
+	if errors.Is(err, errNoScores) && b.bareNames {
+		dailyUsers, names := b.resolveBareNames(s, msg.GuildID, parseBareNameScores(msg.Content))
+		return dailyUsers, names, "bare names"
+	}
+	return dailyUsers, mentionNames(msg.Mentions), "mentions"
+}
+
+// Whether a user is excluded from or opted out of a guild's leaderboards. A
+// failed lookup is logged and treated as not excluded.
+func (b *Bot) excludedUser(guildID, userID string) bool {
+	var excluded int
+	err := b.stmts.isExcluded.QueryRow(guildID, userID).Scan(&excluded)
+	if err != nil && err != sql.ErrNoRows {
+		slog.Error("Error checking exclusion list", "err", err)
+	}
+	return err == nil
+}
+
+// A parsed score the way the Wordle bot writes it: "3/6", "X/6" or "?/6"
+func scoreResult(score int) string {
+	switch score {
+	case failScore:
+		return "X/6"
+	case unknownScore:
+		return "?/6"
+	default:
+		return fmt.Sprintf("%d/6", score)
+	}
+}
+
+// Congratulate the players with the day's lowest score, listing everyone who
+// tied for it. Returns "" if nobody solved the puzzle.
+func winnersOfTheDay(dailyUsers map[string]int) string {
+	best := failScore
+	var winners []string
+	for userID, score := range dailyUsers {
+		switch {
+		case score == unknownScore:
+			continue
+		case score < best:
+			best = score
+			winners = []string{userID}
+		case score == best && score < failScore:
+			winners = append(winners, userID)
+		}
+	}
+	if len(winners) == 0 {
+		return ""
+	}
+
+	sort.Strings(winners)
+	mentions := make([]string, len(winners))
+	for i, userID := range winners {
+		mentions[i] = mention(userID)
+	}
+	if len(winners) == 1 {
+		return fmt.Sprintf("🏆 Winner of the day: %s with %d/6, congratulations!", mentions[0], best)
+	}
+	return fmt.Sprintf("🏆 Winners of the day: %s with %d/6 each, congratulations!", strings.Join(mentions, ", "), best)
+}
+
+// Name the players with the day's highest score, ties listed, fails counting
+// as the worst. Returns "" unless at least two scores differ, so a lone
+// player or a tie for everyone isn't roasted.
+func woodenSpoonOfTheDay(dailyUsers map[string]int) string {
+	worst, best := 0, failScore+1
+	var spoons []string
+	for userID, score := range dailyUsers {
+		if score == unknownScore {
+			continue
+		}
+		best = min(best, score)
+		switch {
+		case score > worst:
+			worst = score
+			spoons = []string{userID}
+		case score == worst:
+			spoons = append(spoons, userID)
+		}
+	}
+	if len(spoons) == 0 || worst == best {
+		return ""
+	}
+
+	sort.Strings(spoons)
+	mentions := make([]string, len(spoons))
+	for i, userID := range spoons {
+		mentions[i] = mention(userID)
+	}
+	return fmt.Sprintf("🥄 Wooden spoon: %s with %s. Better luck tomorrow!", strings.Join(mentions, ", "), scoreResult(worst))
+}
+
+// The date a results message belongs to: the day it was posted on in the
+// bot's timezone. Used for dedup, streaks and the windowed leaderboards alike.
+func (b *Bot) puzzleDate(msg *discordgo.Message) string {
+	posted := msg.Timestamp
+	if posted.IsZero() {
+		posted = time.Now()
+	}
+	return posted.In(b.loc).Format(dateLayout)
+}
+
+// Extract the puzzle number from a header such as "Wordle No. 1,234", or 0 if
+// there is none. The "No." prefix and thousands separators are optional.
+func parsePuzzleNumber(message string) int {
+	match := puzzleRegex.FindStringSubmatch(message)
+	if match == nil {
+		return 0
+	}
+	number, err := strconv.Atoi(strings.ReplaceAll(match[1], ",", ""))
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+// Check whether a board already processed this puzzle. Messages without a
+// puzzle number are matched on the date they were processed instead.
+func (b *Bot) alreadyProcessed(bd board, day resultDay) bool {
+	var exists int
+	var err error
+	if day.puzzle > 0 {
+		err = b.db.QueryRow("SELECT 1 FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND puzzle_number = ?", bd.guildID, bd.channelID, bd.game, day.puzzle).Scan(&exists)
+	} else {
+		err = b.db.QueryRow("SELECT 1 FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND puzzle_number = 0 AND result_date = ?", bd.guildID, bd.channelID, bd.game, day.date).Scan(&exists)
+	}
+	if err != nil && err != sql.ErrNoRows {
+		slog.Error("Error checking processed days", "err", err)
+	}
+	return err == nil
+}
+
+// Record that a board's results for a puzzle have been processed
+func (b *Bot) markProcessed(tx *sql.Tx, bd board, day resultDay) error {
+	_, err := tx.Exec("INSERT INTO processed_days (guild_id, channel_id, game, puzzle_number, result_date) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING", bd.guildID, bd.channelID, bd.game, day.puzzle, day.date)
+	if err != nil {
+		return fmt.Errorf("recording processed day: %w", err)
+	}
+	return nil
+}
+
+// A results message with no score attached to any mention
+var errNoScores = errors.New("no scores found")
+
+// A results message whose players are all excluded or opted out; it is
+// errNoScores too, but says nothing about the parser
+var errEveryoneExcluded = fmt.Errorf("%w for anyone not excluded", errNoScores)
+
+// Parse a results message without touching Discord or the database: the
+// puzzle number (0 if the header has none) and user ID -> score for every
+// mentioned player. A message without any scores returns errNoScores, along
+// with the puzzle number and an empty map.
+func parseResults(message string) (int, map[string]int, error) {
+	puzzle := parsePuzzleNumber(message)
+	scores := parseDailyScores(message)
+	if len(scores) == 0 {
+		return puzzle, scores, errNoScores
+	}
+	return puzzle, scores, nil
+}
+
+// Extract username -> score from a results message. Scores may be on the same
+// line as the mentions ("3/6: @a @b") or within the next few lines after them,
+// with the emoji grids in between ignored.
+func parseDailyScores(message string) map[string]int {
+	dailyUsers := make(map[string]int)
+
+	var pending []string // users mentioned on a line that had no score
+	pendingAge := 0      // lines seen since the pending users were mentioned
+
+	for _, line := range strings.Split(message, "\n") {
+		// Grid lines carry no names or scores
+		if isEmojiGridLine(line) {
+			continue
+		}
+
+		usernames := userRegex.FindAllString(line, -1)
+		scoreMatch := scoreRegex.FindStringSubmatch(line)
+		if scoreMatch == nil {
+			if len(usernames) > 0 {
+				pending = usernames
+				pendingAge = 0
+			} else if pending != nil {
+				pendingAge++
+				if pendingAge > scoreLookahead {
+					pending = nil // Too far away to belong to these users
+				}
+			}
+			continue
+		}
+
+		// A score with no mentions on its line belongs to the pending users
+		if len(usernames) == 0 {
+			usernames = pending
+		}
+		pending = nil
+
+		score := parseScore(scoreMatch[1])
+		for _, user := range usernames {
+			user = cleanUsername(user) // Normalize the username
+			dailyUsers[user] = score   // Add user to the daily user map
+		}
+	}
+
+	return dailyUsers
+}
+
+// Fallback for servers where the Wordle bot writes bare display names instead
+// of mentions: take the name leading a score line ("Alice 3/6"), or else the
+// names listed after the score ("3/6: Alice Bob"). Returns name -> score.
+// Names containing spaces can't be told apart, hence this is opt-in.
+func parseBareNameScores(message string) map[string]int {
+	byName := make(map[string]int)
+	for _, line := range strings.Split(message, "\n") {
+		if isEmojiGridLine(line) {
+			continue
+		}
+		line = cleanDisplayName(line) // Compose accents so trimNameToken keeps them
+		loc := scoreRegex.FindStringSubmatchIndex(line)
+		if loc == nil {
+			continue
+		}
+
+		var names []string
+		if lead := strings.Fields(trimNameToken(line[:loc[2]])); len(lead) > 0 {
+			names = lead[len(lead)-1:] // The token right before the score
+		} else {
+			names = strings.Fields(line[loc[3]+len("/6"):])
+		}
+
+		score := parseScore(line[loc[2]:loc[3]])
+		for _, name := range names {
+			if name = trimNameToken(name); name != "" {
+				byName[name] = score
+			}
+		}
+	}
+	return byName
+}
+
+// Strip the emoji, markdown and punctuation around a bare name
+func trimNameToken(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Look up the guild members behind bare names, returning user ID -> score and
+// user ID -> display name. Names matching no member are logged and skipped.
+func (b *Bot) resolveBareNames(s *discordgo.Session, guildID string, byName map[string]int) (map[string]int, map[string]string) {
+	dailyUsers := make(map[string]int, len(byName))
+	names := make(map[string]string, len(byName))
+	for name, score := range byName {
+		member, err := findMemberByName(s, guildID, name)
+		if err != nil {
+			slog.Error("Error searching guild members", "guild", guildID, "name", name, "err", err)
+			continue
+		}
+		if member == nil {
+			slog.Warn("No guild member matches bare name in results", "guild", guildID, "name", name)
+			continue
+		}
+		dailyUsers[member.User.ID] = score
+		names[member.User.ID] = name
+	}
+	return dailyUsers, names
+}
+
+// Find the guild member whose nickname, display name or username matches
+// name once both are normalized. Returns nil if nobody matches.
+func findMemberByName(s *discordgo.Session, guildID, name string) (*discordgo.Member, error) {
+	members, err := s.GuildMembersSearch(guildID, name, 10)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range members {
+		for _, candidate := range []string{m.Nick, m.User.GlobalName, m.User.Username} {
+			if candidate != "" && normalizeName(candidate) == normalizeName(name) {
+				return m, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// Convert the guess count captured by scoreRegex to points:
+//   - "1" to "6" solved in that many guesses
+//   - "X" or "x" failed, scored as penalties.fail when recorded
+//   - "?" unknown, skipped without a score or a miss penalty
+func parseScore(guesses string) int {
+	switch guesses {
+	case "X", "x":
+		return failScore
+	case "?":
+		return unknownScore
+	}
+	score, _ := strconv.Atoi(guesses) // e.g., "3" from "3/6"
+	return score
+}
+
+// Report whether a line consists only of Wordle grid squares, in any colour scheme
+func isEmojiGridLine(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+	for _, r := range line {
+		if _, ok := gridSquares[r]; !ok && !isGridFiller(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Helper method to clean and format usernames. A "<@123>" or "<@!123>"
+// mention becomes the bare user ID "123", which is what scores are keyed on;
+// a plain "@Name" becomes the normalized name, see normalizeName.
+func cleanUsername(username string) string {
+	username = cleanDisplayName(username)     // Drops zero-width characters hiding the markup
+	username = strings.Trim(username, "@<>!") // Remove mention markup if present
+	return normalizeName(username)
+}
+
+// Display names of the users mentioned in a message, keyed by user ID
+func mentionNames(mentions []*discordgo.User) map[string]string {
+	names := make(map[string]string, len(mentions))
+	for _, user := range mentions {
+		names[user.ID] = cleanDisplayName(user.DisplayName())
+	}
+	return names
+}
+
+// Apply a day's results in a single transaction, so a crash or error
+// mid-update can't leave some users scored and others not
+func (b *Bot) updateScoresBasedOnResults(bd board, dailyUsers map[string]int, names map[string]string, day resultDay) error {
+	tx, err := b.db.Begin()
+	if err != nil {
+		return fmt.Errorf("starting daily update: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	if err := b.recordResults(tx, bd, dailyUsers, names, day); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("committing daily update: %w", err)
+	}
+	return nil
+}
+
+// Write a day's results within tx: score the players who posted, penalize
+// the board's players who didn't, and mark the day processed
+func (b *Bot) recordResults(tx *sql.Tx, bd board, dailyUsers map[string]int, names map[string]string, day resultDay) error {
+	// Get all users already on this board. Only those who had joined by
+	// this day can miss it: a day from before someone's first recorded
+	// result, such as a backfilled one, is never held against them. Nor is
+	// any day missed after leaving the server.
+	rows, err := tx.Query("SELECT user_id, joined_date, active FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ?", bd.guildID, bd.channelID, bd.game)
+	if err != nil {
+		return fmt.Errorf("querying database for users: %w", err)
+	}
+	defer rows.Close()
+
+	// Build a set of all users in the database
+	dbUsers := make(map[string]bool)
+	for rows.Next() {
+		var userID, joined string
+		var active bool
+		if err := rows.Scan(&userID, &joined, &active); err != nil {
+			return fmt.Errorf("scanning database row: %w", err)
+		}
+		if !active {
+			slog.Debug("Not penalizing inactive user", "guild", bd.guildID, "channel", bd.channelID, "user", userID)
+			dbUsers[userID] = false
+			continue
+		}
+		if joined != "" && day.date < joined {
+			slog.Debug("Not penalizing user for a day before they joined", "guild", bd.guildID, "channel", bd.channelID, "user", userID, "joined", joined)
+			dbUsers[userID] = false
+			continue
+		}
+		dbUsers[userID] = true // Mark the user as existing in the database
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("reading database users: %w", err)
+	}
+	rows.Close()
+
+	// Process the daily results (update cumulative scores and mark processed users)
+	for user, score := range dailyUsers {
+		if score == unknownScore {
+			slog.Debug("Skipping unknown result", "guild", bd.guildID, "channel", bd.channelID, "user", user)
+			dbUsers[user] = false // Present, so no miss penalty either
+			continue
+		}
+		// Mark as a scored day
+		if err := b.updateCumulativeScore(tx, bd, user, names[user], score, true, day); err != nil {
+			return err
+		}
+		dbUsers[user] = false // Mark this user as "processed" (present in results)
+	}
+
+	// Add miss penalties for users not in daily results
+	for user, present := range dbUsers {
+		if !present {
+			continue
+		}
+		excluded, err := b.isExcluded(tx, bd.guildID, user)
+		if err != nil {
+			return err
+		}
+		if excluded {
+			continue
+		}
+		away, err := onVacation(tx, bd.guildID, user, day.date)
+		if err != nil {
+			return err
+		}
+		if away {
+			slog.Debug("Not penalizing user on vacation", "guild", bd.guildID, "channel", bd.channelID, "user", user)
+			continue
+		}
+		slog.Debug("Adding penalty for absent user", "guild", bd.guildID, "channel", bd.channelID, "user", user)
+		// Penalty without incrementing days
+		if err := b.updateCumulativeScore(tx, bd, user, "", b.penalties.miss, false, day); err != nil {
+			return err
+		}
+	}
+
+	return b.markProcessed(tx, bd, day)
+}
+
+// A day's result as recorded: what it adds to the total in golf and in points
+// scoring, and whether it was played and solved
+type dayResult struct {
+	score  int  // guesses, or the fail or miss penalty
+	points int  // points earned in points scoring
+	played bool // false for a miss penalty
+	failed bool // played but not solved
+}
+
+// How a parsed Wordle score is recorded; played is false for a missed day,
+// and a played failScore is recorded as the fail penalty
+func (b *Bot) wordleResult(score int, played bool) dayResult {
+	r := dayResult{score: score, points: b.scoring.pointsFor(score, played), played: played}
+	if played && score == failScore {
+		r.failed = true
+		r.score = b.penalties.fail
+	}
+	return r
+}
+
+// Add one day's Wordle score for a user, see addDayResult
+func (b *Bot) updateCumulativeScore(tx *sql.Tx, bd board, userID, displayName string, score int, incrementDays bool, day resultDay) error {
+	return b.addDayResult(tx, bd, userID, displayName, b.wordleResult(score, incrementDays), day)
+}
+
+// Add one day's result for a user; with points scoring the total grows by
+// the points it earns instead of its score. displayName refreshes the cached
+// username when known; an empty name leaves the stored one untouched. A
+// penalty for someone with no row yet is dropped: users join a board by
+// playing, so every row on it has played at least once.
+func (b *Bot) addDayResult(tx *sql.Tx, bd board, userID, displayName string, r dayResult, day resultDay) error {
+	var currentStreak, maxStreak int
+
+	// Excluded users are never scored
+	excluded, err := b.isExcluded(tx, bd.guildID, userID)
+	if err != nil {
+		return err
+	}
+	if excluded {
+		slog.Debug("Skipping excluded user", "guild", bd.guildID, "user", userID)
+		return nil
+	}
+
+	// Check if the user already exists in the database
+	err = tx.Stmt(b.stmts.selectUser).QueryRow(bd.guildID, bd.channelID, bd.game, userID).Scan(&currentStreak, &maxStreak)
+	exists := err == nil
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("querying user %s: %w", userID, err)
+	}
+	if !exists && !r.played {
+		slog.Debug("Skipping penalty for user who has never played", "guild", bd.guildID, "channel", bd.channelID, "user", userID)
+		return nil
+	}
+
+	// Record the per-day result so time-windowed leaderboards can be computed
+	played, failed := 0, 0
+	if r.played {
+		played = 1
+	}
+	if r.failed {
+		failed = 1
+	}
+	_, err = tx.Stmt(b.stmts.insertDailyResult).Exec(bd.guildID, bd.channelID, bd.game, userID, day.date, day.puzzle, r.score, played, failed, r.points)
+	if err != nil {
+		return fmt.Errorf("recording daily result for %s: %w", userID, err)
+	}
+
+	// What the day adds to the leaderboard total
+	score := r.score
+	if b.scoring.usesPoints() {
+		score = r.points
+	}
+
+	u := scoreUpdate{displayName: displayName, score: score, played: r.played, failed: r.failed, date: day.date}
+	switch {
+	case !exists:
+		// A new player starts on a one day streak
+		u.streak, u.maxStreak = 1, 1
+	case r.played:
+		// Extend the streak only if they also played the previous day,
+		// or the last day before a vacation
+		previous, err := streakPreviousDate(tx, bd, userID, day.date)
+		if err != nil {
+			return err
+		}
+		playedYesterday, err := b.playedOn(tx, bd, userID, previous)
+		if err != nil {
+			return err
+		}
+		u.streak = 1
+		if playedYesterday {
+			u.streak = currentStreak + 1
+		}
+		u.maxStreak = max(maxStreak, u.streak)
+	default:
+		u.maxStreak = maxStreak // Missed day breaks the streak
+	}
+	if err := b.store.UpdateScore(tx, bd, userID, u); err != nil {
+		return fmt.Errorf("updating score of %s: %w", userID, err)
+	}
+	return nil
+}
+
+// Check whether a user has a scored (non-penalty) result on a date
+func (b *Bot) playedOn(tx *sql.Tx, bd board, userID, date string) (bool, error) {
+	var exists int
+	err := tx.Stmt(b.stmts.playedOn).QueryRow(bd.guildID, bd.channelID, bd.game, userID, date).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking previous day's result: %w", err)
+	}
+	return true, nil
+}
+
+// The result_date of the day before date
+func previousDate(date string) string {
+	t, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return ""
+	}
+	return t.AddDate(0, 0, -1).Format(dateLayout)
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestParseDailyScoresInlineFormat(t *testing.T) {
+	message := "Here are yesterday's results:\n" +
+		"👑 3/6: <@111> <@222>\n" +
+		"4/6: <@333>\n" +
+		"X/6: <@444>"
+	want := map[string]int{"111": 3, "222": 3, "333": 4, "444": 7}
+	if got := parseDailyScores(message); !maps.Equal(got, want) {
+		t.Errorf("parseDailyScores = %v, want %v", got, want)
+	}
+}
+
+func TestParseDailyScoresMultiLineFormat(t *testing.T) {
+	message := "Here are yesterday's results:\n" +
+		"<@111>\n" +
+		"🟨⬛⬛⬛⬛\n" +
+		"⬛🟩🟨⬛⬛\n" +
+		"🟩🟩🟩🟩🟩\n" +
+		"3/6\n" +
+		"<@222> <@333>\n" +
+		"🟩🟩🟩🟩🟩\n" +
+		"1/6"
+	want := map[string]int{"111": 3, "222": 1, "333": 1}
+	if got := parseDailyScores(message); !maps.Equal(got, want) {
+		t.Errorf("parseDailyScores = %v, want %v", got, want)
+	}
+}
+
+func TestParseDailyScoresForgetsDistantMentions(t *testing.T) {
+	// A score more than scoreLookahead lines after a mention isn't its score
+	message := "<@111> played\nline one\nline two\nline three\nline four\n3/6"
+	if got := parseDailyScores(message); len(got) != 0 {
+		t.Errorf("parseDailyScores = %v, want no scores", got)
+	}
+}
+
+func TestParsePuzzleNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		want    int
+	}{
+		{"streak header", "Your group is on a 5 day streak! 🔥 Wordle No. 1,203", 1203},
+		{"no space after No.", "Wordle No.1,203 results", 1203},
+		{"No without a dot", "Wordle No 987", 987},
+		{"hash", "Wordle #1234 4/6", 1234},
+		{"bare number", "Wordle 1,234 3/6", 1234},
+		{"lowercase", "wordle no. 1203", 1203},
+		{"millions", "Wordle No. 1,000,000", 1000000},
+		{"header on a later line", "Here are yesterday's results:\nWordle No. 1,203\n3/6: <@111>", 1203},
+		{"score is not a puzzle number", "Wordle 3/6", 0},
+		{"no header", "Here are yesterday's results:\n3/6: <@111>", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parsePuzzleNumber(tt.message); got != tt.want {
+				t.Errorf("parsePuzzleNumber(%q) = %d, want %d", tt.message, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreRegex(t *testing.T) {
+	tests := []struct {
+		line string
+		want string // the captured guesses, "" for no match
+	}{
+		{"👑 3/6: <@111> <@222>", "3"},
+		{"**4/6**: <@333>", "4"},
+		{"5/6: <@444>", "5"},
+		{"6/6: <@555>", "6"},
+		{"X/6: <@666>", "X"},
+		{"Wordle 1,203 2/6", "2"},
+		{"(3/6)", "3"},
+		{"3/6", "3"},
+		{"Posted 12/6", ""},
+		{"See you 3/6/2026", ""},
+		{"Averaging 1.5/6", ""},
+		{"7/6: <@111>", ""},
+		{"0/6: <@111>", ""},
+		{"3/60 guesses", ""},
+		{"1,3/6", ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if match := scoreRegex.FindStringSubmatch(tt.line); match != nil {
+			got = match[1]
+		}
+		if got != tt.want {
+			t.Errorf("scoreRegex on %q captured %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestParseDailyScoresIgnoresDates(t *testing.T) {
+	message := "Results for 12/6, posted by <@999>\n" +
+		"**4/6**: <@111>\n" +
+		"3/6: <@222>"
+	want := map[string]int{"111": 4, "222": 3}
+	if got := parseDailyScores(message); !maps.Equal(got, want) {
+		t.Errorf("parseDailyScores = %v, want %v", got, want)
+	}
+}
+
+func TestParseScore(t *testing.T) {
+	tests := []struct {
+		guesses string
+		want    int
+	}{
+		{"1", 1},
+		{"6", 6},
+		{"X", failScore},
+		{"x", failScore},
+		{"?", unknownScore},
+	}
+	for _, tt := range tests {
+		if got := parseScore(tt.guesses); got != tt.want {
+			t.Errorf("parseScore(%q) = %d, want %d", tt.guesses, got, tt.want)
+		}
+	}
+}
+
+func TestParseDailyScoresFailAndUnknown(t *testing.T) {
+	message := "x/6: <@111>\nX/6: <@222>\n?/6: <@333>"
+	want := map[string]int{"111": failScore, "222": failScore, "333": unknownScore}
+	if got := parseDailyScores(message); !maps.Equal(got, want) {
+		t.Errorf("parseDailyScores = %v, want %v", got, want)
+	}
+}
+
+func TestPenaltyOnlyUserGetsNoRow(t *testing.T) {
+	b := newTestBot(t)
+	bd := b.boardFor("guild", "")
+
+	// A miss penalty for someone who has never played is dropped
+	tx, err := b.db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.updateCumulativeScore(tx, bd, "111", "", b.penalties.miss, false, resultDay{date: testDate(0)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	var rows int
+	if err := b.db.QueryRow("SELECT COUNT(*) FROM leaderboard WHERE user_id = '111'").Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 0 {
+		t.Errorf("%d leaderboard row(s) for a penalty-only user, want none", rows)
+	}
+
+	// Once they have played, a miss counts against them like anyone else
+	recordDays(t, b, bd,
+		map[string]int{"111": 3, "222": 4},
+		map[string]int{"222": 4},
+	)
+	got := standings(t, b, bd, allTimePeriod)
+	if want := (standing{"111", 3 + b.penalties.miss, 1, 2}); len(got) != 2 || got[1] != want {
+		t.Errorf("standings = %v, want 111 second as %v", got, want)
+	}
+}
+
+func TestConcurrentResultsLoseNoWrites(t *testing.T) {
+	b := newTestBot(t)
+	const players, puzzles = 10, 30
+
+	var mentions []string
+	for i := range players {
+		mentions = append(mentions, fmt.Sprintf("<@%d>", 1001+i))
+	}
+	message := func(puzzle int) *discordgo.Message {
+		return &discordgo.Message{
+			ID:        fmt.Sprint(puzzle),
+			GuildID:   "guild",
+			ChannelID: "channel",
+			Content:   fmt.Sprintf("Wordle No. %d\n%d/6: %s", puzzle, 1+puzzle%6, strings.Join(mentions, " ")),
+			Timestamp: time.Date(2026, 1, puzzle, 12, 0, 0, 0, time.UTC),
+		}
+	}
+
+	// Every message is delivered twice, all at once
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*puzzles)
+	for puzzle := 1; puzzle <= puzzles; puzzle++ {
+		for range 2 {
+			wg.Go(func() {
+				results, err := b.parseResultsMessage(nil, message(puzzle))
+				if err == nil {
+					_, err = b.applyResults(results)
+				}
+				if err != nil {
+					errs <- fmt.Errorf("puzzle %d: %w", puzzle, err)
+				}
+			})
+		}
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	wantTotal := 0
+	for puzzle := 1; puzzle <= puzzles; puzzle++ {
+		wantTotal += 1 + puzzle%6
+	}
+	got := standings(t, b, b.boardFor("guild", "channel"), allTimePeriod)
+	if len(got) != players {
+		t.Errorf("%d players on the board, want %d", len(got), players)
+	}
+	for _, st := range got {
+		if st.total != wantTotal || st.days != puzzles {
+			t.Errorf("%s has %d points over %d days, want %d over %d", st.userID, st.total, st.days, wantTotal, puzzles)
+		}
+	}
+}
+
+func TestParseResults(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    string
+		wantPuzzle int
+		wantScores map[string]int
+		wantErr    error
+	}{
+		{
+			name: "streak summary",
+			message: "**Your group is on a 12 day streak!** 🔥 Here are yesterday's results:\n" +
+				"👑 3/6: <@111>\n" +
+				"4/6: <@222> <@333>\n" +
+				"X/6: <@444>",
+			wantScores: map[string]int{"111": 3, "222": 4, "333": 4, "444": failScore},
+		},
+		{
+			name: "puzzle header",
+			message: "Wordle No. 1,203\n" +
+				"2/6: <@111>\n" +
+				"5/6: <@!222>",
+			wantPuzzle: 1203,
+			wantScores: map[string]int{"111": 2, "222": 5},
+		},
+		{
+			name: "everyone failed",
+			message: "Wordle #1204\n" +
+				"X/6: <@111> <@222>",
+			wantPuzzle: 1204,
+			wantScores: map[string]int{"111": failScore, "222": failScore},
+		},
+		{
+			name: "multi-line grids",
+			message: "Wordle No. 1,205\n" +
+				"<@111>\n" +
+				"⬛🟨⬛⬛⬛\n" +
+				"🟩🟩🟩🟩🟩\n" +
+				"2/6\n" +
+				"<@222>\n" +
+				"⬛⬛⬛⬛⬛\n" +
+				"⬛⬛⬛⬛⬛\n" +
+				"⬛⬛⬛⬛⬛\n" +
+				"⬛⬛⬛⬛⬛\n" +
+				"⬛⬛⬛⬛⬛\n" +
+				"⬛⬛⬛⬛⬛\n" +
+				"X/6",
+			wantPuzzle: 1205,
+			wantScores: map[string]int{"111": 2, "222": failScore},
+		},
+		{
+			name:       "scores without mentions",
+			message:    "Wordle No. 1,206\n3/6\n4/6",
+			wantPuzzle: 1206,
+			wantScores: map[string]int{},
+			wantErr:    errNoScores,
+		},
+		{
+			name:       "mentions without scores",
+			message:    "Nobody has played yet, <@111> <@222>!",
+			wantScores: map[string]int{},
+			wantErr:    errNoScores,
+		},
+		{
+			name:       "empty message",
+			message:    "",
+			wantScores: map[string]int{},
+			wantErr:    errNoScores,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			puzzle, scores, err := parseResults(tt.message)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if puzzle != tt.wantPuzzle {
+				t.Errorf("puzzle = %d, want %d", puzzle, tt.wantPuzzle)
+			}
+			if !maps.Equal(scores, tt.wantScores) {
+				t.Errorf("scores = %v, want %v", scores, tt.wantScores)
+			}
+		})
+	}
+}
+
+func TestParseFailureAlert(t *testing.T) {
+	b := newTestBot(t)
+	b.logChannelID = "log"
+	s, discord := offlineSession(t)
+	if _, err := b.db.Exec("INSERT INTO excluded_users (guild_id, user_id) VALUES (?, ?)", "guild", "111"); err != nil {
+		t.Fatal(err)
+	}
+	process := func(content string) {
+		t.Helper()
+		msg := &discordgo.Message{
+			ID:        "m1",
+			GuildID:   "guild",
+			ChannelID: "channel",
+			Author:    &discordgo.User{ID: "800", Username: "Wordle", Discriminator: "2092", Bot: true},
+			Content:   content,
+			Timestamp: time.Date(2026, 1, 2, 9, 0, 0, 0, time.UTC),
+		}
+		g, ok := b.detectGame(msg)
+		if !ok {
+			t.Fatalf("%q not detected as results", content)
+		}
+		b.processResultsMessage(s, msg, g)
+	}
+
+	// Only the excluded player scored: nothing wrong with the parser
+	process("Here are yesterday's results:\n3/6: <@111>")
+	if sent := discord.messages(); len(sent) != 0 || b.metrics.parseFailures.Load() != 0 {
+		t.Fatalf("excluded-only results sent %q and counted %d parse failures, want neither", sent, b.metrics.parseFailures.Load())
+	}
+
+	process("Here are yesterday's results:\n`3 of 6`: <@222>")
+	sent := discord.messages()
+	if len(sent) != 1 || !strings.Contains(sent[0], "No scores found") || !strings.Contains(sent[0], "https://discord.com/channels/guild/channel/m1") || !strings.Contains(sent[0], "'3 of 6'") {
+		t.Errorf("sent %q, want one alert linking and quoting the message", sent)
+	}
+	if got := b.metrics.parseFailures.Load(); got != 1 {
+		t.Errorf("parse failures = %d, want 1", got)
+	}
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SCORING_MODE values
+const (
+	scoringGolf   = "golf"   // total guesses plus penalties, lowest wins
+	scoringPoints = "points" // points per result from POINTS_MAP, highest wins
+)
+
+// LEADERBOARD_TIEBREAK values, deciding the order of equal averages
+const (
+	tiebreakDays   = "days"   // more days played first
+	tiebreakRecent = "recent" // most recently played first
+)
+
+// How results add up on the leaderboard. Golf, the default, adds each
+// day's guesses and the fail and miss penalties; points mode adds the points
+// POINTS_MAP awards instead. Both are recorded with every daily result (the
+// score and points columns), so displays of guesses work the same in both
+// and !reprocess can rebuild totals after switching.
+type scoring struct {
+	mode     string
+	points   [failScore + 1]int // points for 1-6 guesses, and for an X at failScore
+	miss     int                // points for a day without a result
+	tiebreak string             // what orders players with equal averages
+}
+
+// Points POINTS_MAP starts from: 6 for a solve in one down to 1 for a solve
+// in six, none for an X or a missed day
+var defaultPoints = [failScore + 1]int{0, 6, 5, 4, 3, 2, 1, 0}
+
+// Golf scoring, with the default points recorded alongside
+func defaultScoring() scoring {
+	return scoring{mode: scoringGolf, points: defaultPoints, tiebreak: tiebreakDays}
+}
+
+// Read SCORING_MODE ("golf" or "points"), LEADERBOARD_TIEBREAK ("days" or
+// "recent") and POINTS_MAP, a comma separated list of result:points pairs
+// overriding the defaults, e.g. "1:10,2:8,3:6,4:4,5:2,6:1,X:0,miss:0".
+// Fewer guesses must never earn fewer points, and a miss never more than an X.
+func loadScoring() (scoring, error) {
+	sc := defaultScoring()
+	switch mode := strings.ToLower(strings.TrimSpace(os.Getenv("SCORING_MODE"))); mode {
+	case "", scoringGolf:
+	case scoringPoints:
+		sc.mode = scoringPoints
+	default:
+		return sc, fmt.Errorf("SCORING_MODE must be golf or points, got %q", mode)
+	}
+	switch tiebreak := strings.ToLower(strings.TrimSpace(os.Getenv("LEADERBOARD_TIEBREAK"))); tiebreak {
+	case "", tiebreakDays:
+	case tiebreakRecent:
+		sc.tiebreak = tiebreakRecent
+	default:
+		return sc, fmt.Errorf("LEADERBOARD_TIEBREAK must be days or recent, got %q", tiebreak)
+	}
+
+	raw := strings.TrimSpace(os.Getenv("POINTS_MAP"))
+	if raw == "" {
+		return sc, nil
+	}
+	for _, pair := range strings.Split(raw, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		points, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || points < 0 {
+			return sc, fmt.Errorf("POINTS_MAP entries must look like 3:4 with points of 0 or more, got %q", pair)
+		}
+		switch key = strings.ToLower(strings.TrimSpace(key)); key {
+		case "x":
+			sc.points[failScore] = points
+		case "miss":
+			sc.miss = points
+		default:
+			guesses, err := strconv.Atoi(key)
+			if err != nil || guesses < 1 || guesses > 6 {
+				return sc, fmt.Errorf("POINTS_MAP results must be 1-6, X or miss, got %q", key)
+			}
+			sc.points[guesses] = points
+		}
+	}
+	for guesses := 2; guesses <= failScore; guesses++ {
+		if sc.points[guesses] > sc.points[guesses-1] {
+			return sc, fmt.Errorf("POINTS_MAP gives %s more points than %s", scoreResult(guesses), scoreResult(guesses-1))
+		}
+	}
+	if sc.miss > sc.points[failScore] {
+		return sc, fmt.Errorf("POINTS_MAP gives a missed day more points than X/6")
+	}
+	return sc, nil
+}
+
+// Whether the leaderboard adds points, so higher totals rank first
+func (sc scoring) usesPoints() bool {
+	return sc.mode == scoringPoints
+}
+
+// Points a day's result earns; played is false for a missed day
+func (sc scoring) pointsFor(score int, played bool) int {
+	if !played {
+		return sc.miss
+	}
+	if score < 1 || score > failScore {
+		return 0
+	}
+	return sc.points[score]
+}
+
+// SQL sort direction that puts the best totals and averages first
+func (sc scoring) bestFirst() string {
+	if sc.usesPoints() {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// SQL comparison that holds when the left total or average is better than
+// the right, matching bestFirst
+func (sc scoring) beats() string {
+	if sc.usesPoints() {
+		return ">"
+	}
+	return "<"
+}
+
+// Whether equal averages go to whoever played most recently
+func (sc scoring) breaksTiesByRecency() bool {
+	return sc.tiebreak == tiebreakRecent
+}
+
+// Column of daily_results holding what a day adds to the leaderboard
+func (sc scoring) dailyColumn() string {
+	if sc.usesPoints() {
+		return "points"
+	}
+	return "score"
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// An archived season of a board
+type season struct {
+	id      int64
+	number  int
+	name    string
+	endedOn string // date the season was archived, as dateLayout
+}
+
+// Handle "!reset": show what archiving the season would do, and archive it
+// and clear the leaderboard once repeated as "!reset confirm [name]"
+func (b *Bot) handleResetCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can reset the leaderboard.")
+		return
+	}
+
+	bd := b.boardFor(m.GuildID, m.ChannelID)
+	args := commandArgs(m.Content)
+	if len(args) == 0 || !strings.EqualFold(args[0], "confirm") {
+		next, players, err := b.previewReset(bd)
+		if err != nil {
+			slog.Error("Error previewing reset", "err", err)
+			return
+		}
+		if players == 0 {
+			sendMessage(s, m.ChannelID, "The leaderboard is already empty.")
+			return
+		}
+		sendMessage(s, m.ChannelID, fmt.Sprintf("This will archive the standings of %d player(s) as **Season %d** and clear the leaderboard. Run `%sreset confirm [season name]` to go ahead.", players, next, b.prefix))
+		return
+	}
+
+	name := strings.Join(args[1:], " ")
+	archived, err := b.archiveSeason(bd, name)
+	if err == sql.ErrNoRows {
+		sendMessage(s, m.ChannelID, "The leaderboard is already empty.")
+		return
+	}
+	if err == errSeasonNameTaken {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("There already is a season called **%s**, pick another name.", name))
+		return
+	}
+	if err != nil {
+		slog.Error("Error archiving season", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to reset the leaderboard, nothing was changed.")
+		return
+	}
+	slog.Info("Season archived", "guild", m.GuildID, "channel", m.ChannelID, "admin", m.Author.ID, "season", archived.number, "name", archived.name)
+
+	output := fmt.Sprintf("🏁 **%s is over!** The leaderboard has been reset for a new season.", archived.name)
+	if top, err := b.seasonTop(archived, 3); err != nil {
+		slog.Error("Error fetching season standings", "err", err)
+	} else if top != "" {
+		output += "\nFinal top 3:\n" + top
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Returned by archiveSeason when the board already has a season by that name
+var errSeasonNameTaken = errors.New("season name already taken")
+
+// The number the next archived season of a board gets, and how many players
+// its standings would hold
+func (b *Bot) previewReset(bd board) (int, int, error) {
+	var next, players int
+	err := b.db.QueryRow("SELECT COALESCE(MAX(number), 0) + 1 FROM seasons WHERE guild_id = ? AND channel_id = ?", bd.guildID, bd.channelID).Scan(&next)
+	if err != nil {
+		return 0, 0, err
+	}
+	err = b.db.QueryRow("SELECT COUNT(*) FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND days_played > 0", bd.guildID, bd.channelID, bd.game).Scan(&players)
+	return next, players, err
+}
+
+// Copy a board's leaderboard into a new season and clear it, in one
+// transaction. An empty name means "Season N". The daily results stay, so
+// history and the weekly and monthly leaderboards aren't affected. Returns
+// sql.ErrNoRows if nobody has played yet.
+func (b *Bot) archiveSeason(bd board, name string) (season, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	tx, err := b.db.Begin()
+	if err != nil {
+		return season{}, fmt.Errorf("starting reset: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	var sn season
+	err = tx.QueryRow("SELECT COALESCE(MAX(number), 0) + 1 FROM seasons WHERE guild_id = ? AND channel_id = ?", bd.guildID, bd.channelID).Scan(&sn.number)
+	if err != nil {
+		return sn, fmt.Errorf("numbering season: %w", err)
+	}
+	sn.name = strings.TrimSpace(name)
+	if sn.name == "" {
+		sn.name = fmt.Sprintf("Season %d", sn.number)
+	}
+	var taken int
+	err = tx.QueryRow("SELECT COUNT(*) FROM seasons WHERE guild_id = ? AND channel_id = ? AND LOWER(name) = LOWER(?)", bd.guildID, bd.channelID, sn.name).Scan(&taken)
+	if err != nil {
+		return sn, fmt.Errorf("checking season name: %w", err)
+	}
+	if taken > 0 {
+		return sn, errSeasonNameTaken
+	}
+
+	err = tx.QueryRow("INSERT INTO seasons (guild_id, channel_id, number, name) VALUES (?, ?, ?, ?) RETURNING id", bd.guildID, bd.channelID, sn.number, sn.name).Scan(&sn.id)
+	if err != nil {
+		return sn, fmt.Errorf("creating season: %w", err)
+	}
+
+	// Excluded users are left out, as on the leaderboard itself
+	res, err := tx.Exec(`
+    INSERT INTO season_standings (season_id, user_id, username, score, days_played, max_streak, fails)
+    SELECT ?, user_id, username, score, days_played, max_streak, fails
+    FROM leaderboard
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND days_played > 0
+      AND user_id NOT IN (SELECT user_id FROM excluded_users WHERE guild_id = ?)`, sn.id, bd.guildID, bd.channelID, bd.game, bd.guildID)
+	if err != nil {
+		return sn, fmt.Errorf("archiving standings: %w", err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return sn, fmt.Errorf("archiving standings: %w", err)
+	} else if n == 0 {
+		return sn, sql.ErrNoRows
+	}
+
+	_, err = tx.Exec("DELETE FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ?", bd.guildID, bd.channelID, bd.game)
+	if err != nil {
+		return sn, fmt.Errorf("clearing leaderboard: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return sn, fmt.Errorf("committing reset: %w", err)
+	}
+	return sn, nil
+}
+
+// Load a season's final standings, ordered like the all-time leaderboard
+func (b *Bot) seasonEntries(sn season) ([]leaderboardEntry, error) {
+	rows, err := b.db.Query(`
+    SELECT * FROM (
+        SELECT user_id, username, score AS total, days_played AS days
+        FROM season_standings
+        WHERE season_id = ? AND days_played > 0
+    ) standings
+    ORDER BY `+averageCentsSQL+` `+b.scoring.bestFirst()+`, days DESC, user_id ASC`, sn.id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []leaderboardEntry
+	for rows.Next() {
+		var e leaderboardEntry
+		if err := rows.Scan(&e.userID, &e.username, &e.totalScore, &e.daysPlayed); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+// Lines for the players ranked within the top n of a season, ties included
+func (b *Bot) seasonTop(sn season, n int) (string, error) {
+	entries, err := b.seasonEntries(sn)
+	if err != nil {
+		return "", err
+	}
+	var lines []string
+	for i, rank := range competitionRanks(entries) {
+		if rank > n {
+			break
+		}
+		e := entries[i]
+		lines = append(lines, fmt.Sprintf("%s %s: %s average over %d days", b.theme.medal(rank), mention(e.userID), e.shownAverage(), e.daysPlayed))
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// Handle "!seasons": list the archived seasons with their champions
+func (b *Bot) handleSeasonsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	output, err := b.buildSeasons(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error fetching seasons", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Handle "!season <name or number>": show an archived season's final standings
+func (b *Bot) handleSeasonCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	query := strings.Join(commandArgs(m.Content), " ")
+	if query == "" {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%sseason <name or number>`, see `%[1]sseasons` for the list", b.prefix))
+		return
+	}
+
+	bd := b.boardFor(m.GuildID, m.ChannelID)
+	sn, err := b.findSeason(bd, query)
+	if err == sql.ErrNoRows {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("There is no season called **%s**.", query))
+		return
+	}
+	if err != nil {
+		slog.Error("Error looking up season", "err", err)
+		return
+	}
+	embeds, err := b.buildSeasonStandings(sn)
+	if err != nil {
+		slog.Error("Error fetching season standings", "err", err)
+		return
+	}
+	for _, embed := range embeds {
+		if err := sendEmbed(s, m.ChannelID, embed); err != nil {
+			return
+		}
+	}
+}
+
+// Load a board's archived seasons, oldest first
+func (b *Bot) loadSeasons(bd board) ([]season, error) {
+	rows, err := b.db.Query("SELECT id, number, name, substr(CAST(ended_at AS TEXT), 1, 10) FROM seasons WHERE guild_id = ? AND channel_id = ? ORDER BY number", bd.guildID, bd.channelID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var seasons []season
+	for rows.Next() {
+		var sn season
+		if err := rows.Scan(&sn.id, &sn.number, &sn.name, &sn.endedOn); err != nil {
+			return nil, err
+		}
+		seasons = append(seasons, sn)
+	}
+	return seasons, rows.Err()
+}
+
+// Find an archived season by name, ignoring case, or by number. Returns
+// sql.ErrNoRows if there is none.
+func (b *Bot) findSeason(bd board, query string) (season, error) {
+	var sn season
+	err := b.db.QueryRow(`
+    SELECT id, number, name, substr(CAST(ended_at AS TEXT), 1, 10) FROM seasons
+    WHERE guild_id = ? AND channel_id = ? AND (LOWER(name) = LOWER(?) OR CAST(number AS TEXT) = ?)
+    ORDER BY LOWER(name) = LOWER(?) DESC
+    LIMIT 1`, bd.guildID, bd.channelID, query, strings.TrimPrefix(query, "#"), query).Scan(&sn.id, &sn.number, &sn.name, &sn.endedOn)
+	return sn, err
+}
+
+// List a board's seasons, newest first, each with its champion(s)
+func (b *Bot) buildSeasons(bd board) (string, error) {
+	seasons, err := b.loadSeasons(bd)
+	if err != nil {
+		return "", err
+	}
+	if len(seasons) == 0 {
+		return "No seasons have finished yet, everyone is still playing Season 1.", nil
+	}
+
+	output := "🏆 **Past Seasons**\n"
+	for i := len(seasons) - 1; i >= 0; i-- {
+		sn := seasons[i]
+		entries, err := b.seasonEntries(sn)
+		if err != nil {
+			return "", err
+		}
+		var champions []string
+		for j, rank := range competitionRanks(entries) {
+			if rank > 1 {
+				break
+			}
+			champions = append(champions, mention(entries[j].userID))
+		}
+		line := fmt.Sprintf("**%s** (ended %s)", sn.name, sn.endedOn)
+		if len(champions) > 0 {
+			line += fmt.Sprintf(": %s with %s", strings.Join(champions, ", "), entries[0].shownAverage())
+		}
+		output += line + "\n"
+	}
+	output += fmt.Sprintf("The current season is Season %d.", seasons[len(seasons)-1].number+1)
+	return output, nil
+}
+
+// Build an archived season's final standings as one or more embeds
+func (b *Bot) buildSeasonStandings(sn season) ([]*discordgo.MessageEmbed, error) {
+	entries, err := b.seasonEntries(sn)
+	if err != nil {
+		return nil, err
+	}
+	noun := "players"
+	if len(entries) == 1 {
+		noun = "player"
+	}
+	newEmbed := func() *discordgo.MessageEmbed {
+		return &discordgo.MessageEmbed{
+			Title:  fmt.Sprintf("🏆 %s Final Standings (Average Score)", sn.name),
+			Color:  b.theme.color,
+			Footer: &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d %s ranked • Ended %s", len(entries), noun, sn.endedOn)},
+		}
+	}
+	if len(entries) == 0 {
+		embed := newEmbed()
+		embed.Description = "Nobody played this season."
+		return []*discordgo.MessageEmbed{embed}, nil
+	}
+	return standingsEmbeds(b.standingsLines(entries, sortAverage, false), newEmbed), nil
+}
+
+// The first result_date of the season a board was in on the day before
+// until, "" when no season had ended by then. Only Wordle boards have
+// seasons.
+func (b *Bot) seasonStartBefore(bd board, until string) (string, error) {
+	if bd.game != defaultGame {
+		return "", nil
+	}
+	var since string
+	err := b.db.QueryRow("SELECT COALESCE(MAX(substr(CAST(ended_at AS TEXT), 1, 10)), '') FROM seasons WHERE guild_id = ? AND channel_id = ? AND substr(CAST(ended_at AS TEXT), 1, 10) < ?", bd.guildID, bd.channelID, until).Scan(&since)
+	if err != nil {
+		return "", fmt.Errorf("finding season start: %w", err)
+	}
+	return since, nil
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How often a message is tried before giving up, and the delay before the
+// first retry; each further retry waits twice as long
+const (
+	sendAttempts = 4
+	sendBackoff  = 500 * time.Millisecond
+)
+
+// Discord allows about 5 messages per 5 seconds in a channel; sends beyond
+// that wait their turn instead of running into the rate limit
+const (
+	channelBurst  = 5
+	channelWindow = 5 * time.Second
+)
+
+// Paces the messages sent to each channel, so a burst such as the daily
+// results acknowledgement, leaderboard and rank callouts, or a run of pages,
+// goes out in order without being rate limited
+type channelPacer struct {
+	mu     sync.Mutex
+	sends  map[string][]time.Time // channel ID -> times of its recent sends, oldest first
+	pruned time.Time
+}
+
+var pacer = &channelPacer{sends: make(map[string][]time.Time)}
+
+// Wait until another message may be sent to a channel. The slot is reserved
+// under the lock and waited for outside it, so callers queue up in order.
+func (p *channelPacer) wait(channelID string) {
+	time.Sleep(time.Until(p.reserve(channelID, time.Now())))
+}
+
+// Reserve the next send slot of a channel as of now and return when it is
+func (p *channelPacer) reserve(channelID string, now time.Time) time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Drop channels idle for a whole window now and then so the map doesn't
+	// keep every channel ever sent to
+	if now.Sub(p.pruned) > channelWindow {
+		for id, times := range p.sends {
+			if now.Sub(times[len(times)-1]) >= channelWindow {
+				delete(p.sends, id)
+			}
+		}
+		p.pruned = now
+	}
+
+	recent := p.sends[channelID]
+	for len(recent) > 0 && now.Sub(recent[0]) >= channelWindow {
+		recent = recent[1:]
+	}
+	at := now
+	if len(recent) >= channelBurst {
+		at = recent[len(recent)-channelBurst].Add(channelWindow)
+	}
+	p.sends[channelID] = append(recent, at)
+	return at
+}
+
+// Send a plain text message to a channel, retrying transient failures
+func sendMessage(s *discordgo.Session, channelID, content string) error {
+	return sendWithRetry(channelID, func() error {
+		_, err := s.ChannelMessageSend(channelID, content)
+		return err
+	})
+}
+
+// Send an embed to a channel, retrying transient failures
+func sendEmbed(s *discordgo.Session, channelID string, embed *discordgo.MessageEmbed) error {
+	_, err := sendEmbedMessage(s, channelID, embed)
+	return err
+}
+
+// Send an embed like sendEmbed, returning the message it was posted as
+func sendEmbedMessage(s *discordgo.Session, channelID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	var msg *discordgo.Message
+	err := sendWithRetry(channelID, func() error {
+		var err error
+		msg, err = s.ChannelMessageSendEmbed(channelID, embed)
+		return err
+	})
+	return msg, err
+}
+
+// Replace the embed of an existing message, retrying transient failures
+func editEmbedMessage(s *discordgo.Session, channelID, messageID string, embed *discordgo.MessageEmbed) (*discordgo.Message, error) {
+	var msg *discordgo.Message
+	err := sendWithRetry(channelID, func() error {
+		var err error
+		msg, err = s.ChannelMessageEditEmbed(channelID, messageID, embed)
+		return err
+	})
+	return msg, err
+}
+
+// Upload a file to a channel, retrying transient failures
+func sendFile(s *discordgo.Session, channelID, name string, data []byte) error {
+	return sendWithRetry(channelID, func() error {
+		_, err := s.ChannelFileSend(channelID, name, bytes.NewReader(data)) // Fresh reader per attempt
+		return err
+	})
+}
+
+// Run send until it succeeds, fails permanently or runs out of attempts,
+// pacing each attempt per channel and backing off exponentially in between,
+// or for as long as a rate limit's Retry-After asks. The final error is
+// logged here, so callers only need to check it to stop sending follow-up
+// messages.
+func sendWithRetry(channelID string, send func() error) error {
+	delay := sendBackoff
+	var err error
+	for attempt := 1; attempt <= sendAttempts; attempt++ {
+		pacer.wait(channelID)
+		if err = send(); err == nil {
+			return nil
+		}
+		if !isTransientSendError(err) {
+			slog.Error("Error sending message, not retrying", "channel", channelID, "err", err)
+			return err
+		}
+		if attempt < sendAttempts {
+			wait := delay
+			if after, ok := retryAfter(err); ok && after > wait {
+				wait = after
+			}
+			slog.Warn("Error sending message, retrying", "channel", channelID, "attempt", attempt, "retry_in", wait, "err", err)
+			time.Sleep(wait)
+			delay *= 2
+		}
+	}
+	slog.Error("Error sending message, giving up", "channel", channelID, "attempts", sendAttempts, "err", err)
+	return err
+}
+
+// How long Discord asked us to wait before trying a rate limited request
+// again, from discordgo's rate limit error or the Retry-After header of a 429
+func retryAfter(err error) (time.Duration, bool) {
+	var rateErr *discordgo.RateLimitError
+	if errors.As(err, &rateErr) && rateErr.RateLimit != nil && rateErr.TooManyRequests != nil {
+		return rateErr.RetryAfter, true
+	}
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil || restErr.Response.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	seconds, parseErr := strconv.ParseFloat(restErr.Response.Header.Get("Retry-After"), 64)
+	if parseErr != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds * float64(time.Second)), true
+}
+
+// Report whether a failed send may succeed if tried again: rate limits,
+// Discord server errors and network errors are transient, while any other
+// HTTP error (403 missing access, 404 unknown channel, ...) is permanent
+func isTransientSendError(err error) bool {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return true
+	}
+	code := restErr.Response.StatusCode
+	return code == http.StatusTooManyRequests || code >= 500
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChannelPacer(t *testing.T) {
+	p := &channelPacer{sends: make(map[string][]time.Time)}
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// A burst goes out at once, the next send waits for the window
+	for i := range channelBurst {
+		if at := p.reserve("a", start); !at.Equal(start) {
+			t.Errorf("send %d at %v, want right away", i+1, at.Sub(start))
+		}
+	}
+	if at := p.reserve("a", start); !at.Equal(start.Add(channelWindow)) {
+		t.Errorf("send %d at %v, want after %v", channelBurst+1, at.Sub(start), channelWindow)
+	}
+
+	// Other channels are paced on their own
+	if at := p.reserve("b", start); !at.Equal(start) {
+		t.Errorf("first send to another channel at %v, want right away", at.Sub(start))
+	}
+
+	// Channels idle for a whole window are forgotten
+	later := start.Add(2*channelWindow + time.Second)
+	p.reserve("c", later)
+	if _, ok := p.sends["b"]; ok {
+		t.Error("idle channel b still tracked")
+	}
+	if _, ok := p.sends["a"]; ok {
+		t.Error("idle channel a still tracked")
+	}
+	if len(p.sends) != 1 {
+		t.Errorf("%d channels tracked, want 1", len(p.sends))
+	}
+}
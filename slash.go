@@ -0,0 +1,162 @@
+package main
+
+import (
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Lowest value accepted by the /leaderboard page option
+var minLeaderboardPage = 1.0
+
+// Slash commands registered with Discord at startup. The prefix commands
+// in onMessageCreate are kept working alongside these during the transition.
+var slashCommands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "leaderboard",
+		Description: "Show the Wordle leaderboard",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "period",
+				Description: "Which days to include",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "All time", Value: "all"},
+					{Name: "Last 7 days", Value: "week"},
+					{Name: "This month", Value: "month"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "sort",
+				Description: "What to rank players by",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "Average score", Value: "average"},
+					{Name: "Total points", Value: "total"},
+					{Name: "Wins", Value: "wins"},
+					{Name: "Current streak", Value: "streak"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "page",
+				Description: "Only show this page of the leaderboard",
+				MinValue:    &minLeaderboardPage,
+			},
+		},
+	},
+	{
+		Name:        "stats",
+		Description: "Show a player's Wordle stats",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "user",
+				Description: "Player to look up",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "mystats",
+		Description: "Show your own Wordle stats",
+	},
+}
+
+// Register all slash commands globally for the bot's application
+func registerSlashCommands(s *discordgo.Session) {
+	for _, cmd := range slashCommands {
+		_, err := s.ApplicationCommandCreate(s.State.User.ID, "", cmd)
+		if err != nil {
+			slog.Error("Error registering slash command", "command", cmd.Name, "err", err)
+		}
+	}
+}
+
+// Handle slash command interactions
+func (b *Bot) onInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	if i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	data := i.ApplicationCommandData()
+	var content string
+	var embeds []*discordgo.MessageEmbed
+	var err error
+
+	switch data.Name {
+	case "leaderboard":
+		period := allTimePeriod
+		by := sortAverage
+		page := 0
+		for _, opt := range data.Options {
+			switch opt.Name {
+			case "period":
+				period = parsePeriod(opt.StringValue(), b.now())
+			case "sort":
+				by = parseSort(opt.StringValue())
+			case "page":
+				page = int(opt.IntValue())
+			}
+		}
+		period.sort = by
+		if page > 0 {
+			var embed *discordgo.MessageEmbed
+			embed, err = b.buildLeaderboardPage(b.boardFor(i.GuildID, i.ChannelID), period, page)
+			embeds = []*discordgo.MessageEmbed{embed}
+			break
+		}
+		embeds, err = b.buildLeaderboard(b.boardFor(i.GuildID, i.ChannelID), period)
+	case "stats":
+		user := data.Options[0].UserValue(nil)
+		content, err = b.buildUserStats(b.boardFor(i.GuildID, i.ChannelID), user.ID)
+	case "mystats":
+		content, err = b.buildMyStats(b.boardFor(i.GuildID, i.ChannelID), interactionUser(i).ID)
+	default:
+		return
+	}
+	b.metrics.countCommand(data.Name)
+
+	if err != nil {
+		b.metrics.dbErrors.Add(1)
+		slog.Error("Error handling slash command", "command", data.Name, "err", err)
+		content = "Something went wrong, please try again later."
+		embeds = nil
+	}
+	respondToInteraction(s, i, content, embeds...)
+}
+
+// The user who invoked an interaction (Member is only set inside guilds)
+func interactionUser(i *discordgo.InteractionCreate) *discordgo.User {
+	if i.Member != nil {
+		return i.Member.User
+	}
+	return i.User
+}
+
+// Reply to an interaction with a message and/or embeds. The first embed goes
+// in the response, any others are sent as followups, one per message.
+func respondToInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embeds ...*discordgo.MessageEmbed) {
+	data := &discordgo.InteractionResponseData{Content: content}
+	if len(embeds) > 0 {
+		data.Embeds = embeds[:1]
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	})
+	if err != nil {
+		slog.Error("Error responding to interaction", "err", err)
+		return
+	}
+
+	for _, embed := range embeds[min(1, len(embeds)):] {
+		_, err := s.FollowupMessageCreate(i.Interaction, true, &discordgo.WebhookParams{
+			Embeds: []*discordgo.MessageEmbed{embed},
+		})
+		if err != nil {
+			slog.Error("Error sending interaction followup", "err", err)
+			return
+		}
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !postgres
+
+package main
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// A store on a fresh in-memory SQLite database
+func newTestStore(t *testing.T) store {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // Every connection would get its own empty database
+	t.Cleanup(func() { db.Close() })
+	return newSQLiteStore(db)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Statements used once per user on every processed day, prepared once at
+// startup so the driver doesn't re-parse the SQL for each user
+type statements struct {
+	isExcluded        *sql.Stmt
+	playedOn          *sql.Stmt
+	insertDailyResult *sql.Stmt
+	selectUser        *sql.Stmt
+}
+
+// Prepare the daily update statements; call after initializeDatabase
+func (b *Bot) prepareStatements() error {
+	queries := []struct {
+		stmt  **sql.Stmt
+		query string
+	}{
+		{&b.stmts.isExcluded, "SELECT 1 FROM excluded_users WHERE guild_id = ? AND user_id = ?"},
+		{&b.stmts.playedOn, "SELECT 1 FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND result_date = ? AND played = 1"},
+		{&b.stmts.insertDailyResult, "INSERT INTO daily_results (guild_id, channel_id, game, user_id, result_date, puzzle_number, score, played, failed, points, inserted_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)"},
+		{&b.stmts.selectUser, "SELECT current_streak, max_streak FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?"},
+	}
+
+	for _, q := range queries {
+		stmt, err := b.db.Prepare(q.query)
+		if err != nil {
+			return fmt.Errorf("preparing %q: %w", q.query, err)
+		}
+		*q.stmt = stmt
+	}
+	return b.store.Prepare()
+}
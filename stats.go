@@ -0,0 +1,514 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// One user's stored record in a guild
+type userStats struct {
+	totalScore int
+	daysPlayed int
+	fails      int
+	rank       int // position on the all-time leaderboard, ties share a rank
+	ranked     int // number of players on the all-time leaderboard
+	best       sql.NullInt64
+	worst      sql.NullInt64
+}
+
+// Load a user's stats, or nil if they have never played in this guild
+func (b *Bot) loadUserStats(bd board, userID string) (*userStats, error) {
+	st, err := b.store.UserStats(bd, userID)
+	if err != nil || st == nil {
+		return nil, err
+	}
+
+	// Rank as on the all-time leaderboard
+	r, err := b.loadUserRank(bd, userID)
+	if err != nil {
+		return nil, err
+	}
+	if r != nil {
+		st.rank, st.ranked = r.rank, r.ranked
+	}
+	return st, nil
+}
+
+// Build a summary of one user's record
+func (b *Bot) buildUserStats(bd board, userID string) (string, error) {
+	st, err := b.loadUserStats(bd, userID)
+	if err != nil {
+		return "", err
+	}
+	if st == nil {
+		return fmt.Sprintf("No results recorded for %s yet.", mention(userID)), nil
+	}
+	return formatUserStats(userID, st), nil
+}
+
+// Build the caller's own summary, with a friendlier message for new players
+func (b *Bot) buildMyStats(bd board, userID string) (string, error) {
+	st, err := b.loadUserStats(bd, userID)
+	if err != nil {
+		return "", err
+	}
+	if st == nil {
+		return "You don't have any recorded results yet. Play today's Wordle and you'll show up after the next daily results!", nil
+	}
+	return formatUserStats(userID, st), nil
+}
+
+// Format a user's stats for a Discord message
+func formatUserStats(userID string, st *userStats) string {
+	average := leaderboardEntry{totalScore: st.totalScore, daysPlayed: st.daysPlayed}.shownAverage()
+	wins := st.daysPlayed - st.fails
+	winRate := float64(wins) / float64(st.daysPlayed) * 100
+
+	output := fmt.Sprintf("📈 **Stats for %s**\n", mention(userID))
+	output += fmt.Sprintf("Rank: %d of %d\n", st.rank, st.ranked)
+	output += fmt.Sprintf("Games played: %d\nWins: %d\nFails: %d\nWin rate: %.1f%%\n", st.daysPlayed, wins, st.fails, winRate)
+	output += fmt.Sprintf("Total score: %d\nAverage: %s", st.totalScore, average)
+	if st.best.Valid {
+		output += fmt.Sprintf("\nBest: %s\nWorst: %s", formatScore(int(st.best.Int64)), formatScore(int(st.worst.Int64)))
+	}
+	return output
+}
+
+// Render a stored daily score the way Wordle shows it ("3/6", "X/6")
+func formatScore(score int) string {
+	if score == failScore {
+		return "X/6"
+	}
+	return fmt.Sprintf("%d/6", score)
+}
+
+// A player's position on the all-time leaderboard
+type userRank struct {
+	rank    int    // ties share a rank, as on the leaderboard
+	ranked  int    // number of players on the leaderboard
+	average string // as shown on the leaderboard
+}
+
+// Find a user's all-time rank in one query, or nil if they aren't on the
+// leaderboard. Players rank ahead when their average, rounded to the cent as
+// shown, is better under the scoring mode, so ties share a rank the way
+// competitionRanks gives it on the leaderboard.
+func (b *Bot) loadUserRank(bd board, userID string) (*userRank, error) {
+	var r userRank
+	var e leaderboardEntry
+	err := b.db.QueryRow(`
+    WITH players AS (
+        SELECT user_id, score AS total, days_played AS days
+        FROM leaderboard
+        WHERE guild_id = ? AND channel_id = ? AND game = ? AND days_played > 0 AND active = 1
+          AND user_id NOT IN (SELECT user_id FROM excluded_users WHERE guild_id = ?)
+    ),
+    ranked AS (SELECT user_id, total, days, `+averageCentsSQL+` AS cents FROM players)
+    SELECT me.total, me.days,
+           (SELECT COUNT(*) FROM ranked o WHERE o.cents `+b.scoring.beats()+` me.cents) + 1,
+           (SELECT COUNT(*) FROM ranked)
+    FROM ranked me
+    WHERE me.user_id = ?`, bd.guildID, bd.channelID, bd.game, bd.guildID, userID).Scan(&e.totalScore, &e.daysPlayed, &r.rank, &r.ranked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("loading rank of %s: %w", userID, err)
+	}
+	r.average = e.shownAverage()
+	return &r, nil
+}
+
+// Build the "!rank" reply; self switches between "You are" and "<@id> is"
+func (b *Bot) buildRank(bd board, userID string, self bool) (string, error) {
+	r, err := b.loadUserRank(bd, userID)
+	if err != nil {
+		return "", err
+	}
+	subject := fmt.Sprintf("%s is", mention(userID))
+	if self {
+		subject = "You are"
+	}
+	if r == nil {
+		return fmt.Sprintf("%s not on the leaderboard yet.", subject), nil
+	}
+	return fmt.Sprintf("%s ranked %s of %d with an average of %s", subject, ordinal(r.rank), r.ranked, r.average), nil
+}
+
+// Format a rank as "1st", "2nd", "3rd", "4th", "11th", "21st", ...
+func ordinal(n int) string {
+	suffix := "th"
+	switch n % 10 {
+	case 1:
+		suffix = "st"
+	case 2:
+		suffix = "nd"
+	case 3:
+		suffix = "rd"
+	}
+	if n%100 >= 11 && n%100 <= 13 {
+		suffix = "th"
+	}
+	return fmt.Sprintf("%d%s", n, suffix)
+}
+
+// Days shown by !history unless another count is given, and the most allowed
+const (
+	defaultHistoryDays = 14
+	maxHistoryDays     = 60
+)
+
+// Bars for scores 1-6 (taller means more guesses), plus markers for an X/6
+// and a missed day
+var (
+	sparkBars   = []rune("▁▂▃▅▆▇")
+	sparkFail   = '✖'
+	sparkMissed = '·'
+)
+
+// Width in characters of the longest !distribution bar
+const distributionBarWidth = 16
+
+// Build a user's guess distribution: how often they solved in 1-6 guesses or
+// failed, as bars scaled to the most common outcome
+func (b *Bot) buildDistribution(bd board, userID string) (string, error) {
+	rows, err := b.db.Query(`
+    SELECT score, failed, COUNT(*)
+    FROM daily_results
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND played = 1
+    GROUP BY score, failed`, bd.guildID, bd.channelID, bd.game, userID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var counts [7]int // guesses 1-6 at 0-5, fails at 6
+	for rows.Next() {
+		var score, failed, n int
+		if err := rows.Scan(&score, &failed, &n); err != nil {
+			return "", err
+		}
+		switch {
+		case failed == 1:
+			counts[6] += n
+		case score >= 1 && score <= 6:
+			counts[score-1] += n
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	total, most := 0, 0
+	for _, n := range counts {
+		total += n
+		most = max(most, n)
+	}
+	if total == 0 {
+		return fmt.Sprintf("No results recorded for %s yet.", mention(userID)), nil
+	}
+
+	output := fmt.Sprintf("📊 **Guess distribution for %s** (%d games)\n```\n", mention(userID), total)
+	for i, n := range counts {
+		label := strconv.Itoa(i + 1)
+		if i == 6 {
+			label = "X"
+		}
+		bar := strings.Repeat("█", (n*distributionBarWidth+most-1)/most) // Any count > 0 gets a block
+		output += fmt.Sprintf("%s %-*s %d (%.0f%%)\n", label, distributionBarWidth, bar, n, float64(n)*100/float64(total))
+	}
+	output += "```"
+	return output, nil
+}
+
+// Build a sparkline of a user's last days of results
+func (b *Bot) buildHistory(bd board, userID string, days int) (string, error) {
+	rows, err := b.db.Query(`
+    SELECT score, played, failed
+    FROM daily_results
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?
+    ORDER BY result_date DESC, id DESC
+    LIMIT ?`, bd.guildID, bd.channelID, bd.game, userID, days)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	// Collected newest first, drawn oldest first
+	var spark, numbers []string
+	for rows.Next() {
+		var score, played, failed int
+		if err := rows.Scan(&score, &played, &failed); err != nil {
+			return "", err
+		}
+		switch {
+		case played == 0:
+			spark = append(spark, string(sparkMissed))
+			numbers = append(numbers, "-")
+		case failed == 1:
+			spark = append(spark, string(sparkFail))
+			numbers = append(numbers, "X")
+		default:
+			spark = append(spark, string(sparkBars[min(max(score, 1), len(sparkBars))-1]))
+			numbers = append(numbers, strconv.Itoa(score))
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	if len(spark) == 0 {
+		return fmt.Sprintf("No results recorded for %s yet.", mention(userID)), nil
+	}
+	slices.Reverse(spark)
+	slices.Reverse(numbers)
+
+	output := fmt.Sprintf("📉 **Last %d days for %s** (%c = X/6, %c = missed)\n", len(spark), mention(userID), sparkFail, sparkMissed)
+	output += fmt.Sprintf("```\n%s\n%s\n```", strings.Join(spark, ""), strings.Join(numbers, " "))
+	return output, nil
+}
+
+// Show how everyone on a board did on one Wordle puzzle, best to worst
+func (b *Bot) buildPuzzle(bd board, puzzle int) (string, error) {
+	played, missed, err := b.loadDayResults(`
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND puzzle_number = ?`, bd.guildID, bd.channelID, bd.game, puzzle)
+	if err != nil {
+		return "", err
+	}
+	if len(played) == 0 && len(missed) == 0 {
+		return fmt.Sprintf("No data for Wordle %d.", puzzle), nil
+	}
+	return formatDayResults(fmt.Sprintf("🧩 **Results for Wordle %d**", puzzle), played, missed), nil
+}
+
+// Show the results recorded today, in the bot's timezone
+func (b *Bot) buildToday(bd board) (string, error) {
+	today := b.now().Format(dateLayout)
+	played, missed, err := b.loadDayResults(`
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND result_date = ?`, bd.guildID, bd.channelID, bd.game, today)
+	if err != nil {
+		return "", err
+	}
+	if len(played) == 0 && len(missed) == 0 {
+		return fmt.Sprintf("No results have been recorded today (%s) yet.", today), nil
+	}
+	return formatDayResults(fmt.Sprintf("📅 **Today's results** (%s)", today), played, missed), nil
+}
+
+// Players on a board with no result recorded for a date, leaving out
+// excluded, opted-out and inactive users and those on vacation
+func (b *Bot) pendingPlayers(bd board, date string) ([]string, error) {
+	rows, err := b.db.Query(`
+    SELECT l.user_id
+    FROM leaderboard l
+    WHERE l.guild_id = ? AND l.channel_id = ? AND l.game = ? AND l.active = 1
+      AND l.user_id NOT IN (SELECT user_id FROM excluded_users WHERE guild_id = ?)
+      AND NOT EXISTS (
+        SELECT 1 FROM daily_results d
+        WHERE d.guild_id = l.guild_id AND d.channel_id = l.channel_id AND d.game = l.game AND d.user_id = l.user_id
+          AND d.result_date = ? AND d.played = 1)
+      AND NOT EXISTS (
+        SELECT 1 FROM vacations v
+        WHERE v.guild_id = l.guild_id AND v.user_id = l.user_id AND v.start_date <= ? AND v.end_date >= ?)
+    ORDER BY l.user_id`, bd.guildID, bd.channelID, bd.game, bd.guildID, date, date, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return userIDs, rows.Err()
+}
+
+// List the players who haven't got a result in today yet, as mentions so
+// they get pinged
+func (b *Bot) buildPending(bd board) (string, error) {
+	today := b.now().Format(dateLayout)
+	userIDs, err := b.pendingPlayers(bd, today)
+	if err != nil {
+		return "", err
+	}
+	if len(userIDs) == 0 {
+		return fmt.Sprintf("Everyone has played today (%s)! 🎉", today), nil
+	}
+	mentions := make([]string, len(userIDs))
+	for i, userID := range userIDs {
+		mentions[i] = mention(userID)
+	}
+	return fmt.Sprintf("⏳ **Still to play today** (%s): %s", today, strings.Join(mentions, " ")), nil
+}
+
+// Load one day's results matching a WHERE clause: "<@id>: 3/6" for everyone
+// who played, best to worst, and the IDs of those given a miss penalty
+func (b *Bot) loadDayResults(where string, args ...any) ([]string, []string, error) {
+	rows, err := b.db.Query(`
+    SELECT user_id, score, played, failed
+    FROM daily_results`+where+`
+    ORDER BY played DESC, failed, score, user_id`, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	var played, missed []string
+	for rows.Next() {
+		var userID string
+		var score, didPlay, failed int
+		if err := rows.Scan(&userID, &score, &didPlay, &failed); err != nil {
+			return nil, nil, err
+		}
+		switch {
+		case didPlay == 0:
+			missed = append(missed, userID)
+		case failed == 1:
+			played = append(played, fmt.Sprintf("%s: X/6", mention(userID)))
+		default:
+			played = append(played, fmt.Sprintf("%s: %d/6", mention(userID), score))
+		}
+	}
+	return played, missed, rows.Err()
+}
+
+// Numbered results under a title, followed by who didn't submit
+func formatDayResults(title string, played, missed []string) string {
+	output := title + "\n"
+	for i, line := range played {
+		output += fmt.Sprintf("%d. %s\n", i+1, line)
+	}
+	if len(missed) > 0 {
+		mentions := make([]string, len(missed))
+		for i, userID := range missed {
+			mentions[i] = mention(userID)
+		}
+		output += fmt.Sprintf("Didn't submit: %s\n", strings.Join(mentions, ", "))
+	}
+	return output
+}
+
+// Build trivia about a whole board: puzzles and games tracked, the day with
+// the best average and the most active player
+func (b *Bot) buildServerStats(bd board) (string, error) {
+	var puzzles, games int
+	err := b.db.QueryRow("SELECT COUNT(*) FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ?", bd.guildID, bd.channelID, bd.game).Scan(&puzzles)
+	if err != nil {
+		return "", err
+	}
+	err = b.db.QueryRow("SELECT COUNT(*) FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND played = 1", bd.guildID, bd.channelID, bd.game).Scan(&games)
+	if err != nil {
+		return "", err
+	}
+	if games == 0 {
+		return "No games have been recorded yet.", nil
+	}
+
+	output := "📈 **Server Stats**\n"
+	output += fmt.Sprintf("Puzzles tracked: %d\nGames played: %d\n", puzzles, games)
+
+	// Ties go to the earliest day
+	var bestDate string
+	var bestAverage float64
+	var bestPlayers int
+	err = b.db.QueryRow(`
+    SELECT result_date, AVG(score), COUNT(*)
+    FROM daily_results
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND played = 1
+    GROUP BY result_date
+    ORDER BY AVG(score) ASC, result_date ASC
+    LIMIT 1`, bd.guildID, bd.channelID, bd.game).Scan(&bestDate, &bestAverage, &bestPlayers)
+	if err != nil {
+		return "", err
+	}
+	output += fmt.Sprintf("Best day: %s, averaging %.2f across %d player(s)\n", bestDate, bestAverage, bestPlayers)
+
+	var mostID string
+	var mostGames int
+	err = b.db.QueryRow(`
+    SELECT user_id, COUNT(*)
+    FROM daily_results
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND played = 1
+      AND user_id NOT IN (SELECT user_id FROM excluded_users WHERE guild_id = ?)
+    GROUP BY user_id
+    ORDER BY COUNT(*) DESC, user_id ASC
+    LIMIT 1`, bd.guildID, bd.channelID, bd.game, bd.guildID).Scan(&mostID, &mostGames)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if err == nil {
+		output += fmt.Sprintf("Most games: %s with %d\n", mention(mostID), mostGames)
+	}
+	return output, nil
+}
+
+// Build the !compare embed: both players' all-time numbers side by side and
+// their head-to-head record. Returns a plain reply instead when one of them
+// isn't on the leaderboard.
+func (b *Bot) buildCompare(bd board, userID, otherID string) (*discordgo.MessageEmbed, string, error) {
+	entries, err := b.leaderboardEntries(bd, allTimePeriod)
+	if err != nil {
+		return nil, "", err
+	}
+	ranks := competitionRanks(entries)
+	players := make([]*leaderboardEntry, 2)
+	playerRanks := make([]int, 2)
+	for i := range entries {
+		for j, id := range []string{userID, otherID} {
+			if entries[i].userID == id {
+				players[j], playerRanks[j] = &entries[i], ranks[i]
+			}
+		}
+	}
+	for j, id := range []string{userID, otherID} {
+		if players[j] == nil {
+			return nil, fmt.Sprintf("%s is not on the leaderboard yet.", mention(id)), nil
+		}
+	}
+
+	record, err := b.headToHead(bd, userID, otherID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "⚔️ Head to Head",
+		Color: b.theme.color,
+	}
+	for j, e := range players {
+		name := e.username
+		if name == "" {
+			name = e.userID
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: name,
+			Value: fmt.Sprintf("%s\nRank: %s\nAverage: %s\nGames: %d\nWins: %d\nStreak: %d days",
+				mention(e.userID), ordinal(playerRanks[j]), e.shownAverage(), e.daysPlayed, e.wins, e.streak),
+			Inline: true,
+		})
+	}
+
+	versus := fmt.Sprintf("%s and %s have never played the same puzzle.", mention(userID), mention(otherID))
+	if record.days > 0 {
+		ties := record.days - record.wins - record.losses
+		versus = fmt.Sprintf("Played the same day %d time(s): %s won %d, %s won %d, %d tied.", record.days, mention(userID), record.wins, mention(otherID), record.losses, ties)
+		switch {
+		case record.wins > record.losses:
+			versus += fmt.Sprintf("\n%s leads the rivalry!", mention(userID))
+		case record.losses > record.wins:
+			versus += fmt.Sprintf("\n%s leads the rivalry!", mention(otherID))
+		default:
+			versus += "\nDead even!"
+		}
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{Name: "Head to head", Value: versus})
+	return embed, "", nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestUserRankMatchesLeaderboard(t *testing.T) {
+	for _, mode := range []string{scoringGolf, scoringPoints} {
+		t.Run(mode, func(t *testing.T) {
+			b := newTestBot(t)
+			b.scoring.mode = mode
+			bd := b.boardFor("guild", "")
+			recordDays(t, b, bd,
+				map[string]int{"111": 3, "222": 2, "333": 4, "444": failScore, "555": 5},
+				map[string]int{"111": 3, "222": 4, "333": 2, "444": 4, "555": 6},
+			)
+
+			entries, err := b.leaderboardEntries(bd, allTimePeriod)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, rank := range competitionRanks(entries) {
+				e := entries[i]
+				r, err := b.loadUserRank(bd, e.userID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r == nil || r.rank != rank || r.ranked != len(entries) || r.average != e.shownAverage() {
+					t.Errorf("rank of %s = %+v, want %d of %d with %s", e.userID, r, rank, len(entries), e.shownAverage())
+				}
+			}
+
+			if r, err := b.loadUserRank(bd, "999"); err != nil || r != nil {
+				t.Errorf("rank of a player not on the board = %+v, %v, want nil", r, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Missing puzzle ranges listed by !status before it just counts the rest
+const statusGapLimit = 5
+
+// A run of puzzle numbers never processed, first to last inclusive
+type puzzleGap struct {
+	first, last int
+}
+
+// "1204" or "1204–1206"
+func (g puzzleGap) String() string {
+	if g.first == g.last {
+		return fmt.Sprint(g.first)
+	}
+	return fmt.Sprintf("%d–%d", g.first, g.last)
+}
+
+// Number of puzzles in a run
+func (g puzzleGap) size() int {
+	return g.last - g.first + 1
+}
+
+// Runs missing from a sorted list of distinct puzzle numbers
+func puzzleGaps(puzzles []int) []puzzleGap {
+	var gaps []puzzleGap
+	for i := 1; i < len(puzzles); i++ {
+		if puzzles[i] > puzzles[i-1]+1 {
+			gaps = append(gaps, puzzleGap{first: puzzles[i-1] + 1, last: puzzles[i] - 1})
+		}
+	}
+	return gaps
+}
+
+// The puzzle numbers processed on a board, lowest first
+func (b *Bot) processedPuzzles(bd board) ([]int, error) {
+	rows, err := b.db.Query("SELECT DISTINCT puzzle_number FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ? AND puzzle_number > 0 ORDER BY puzzle_number", bd.guildID, bd.channelID, bd.game)
+	if err != nil {
+		return nil, fmt.Errorf("loading processed puzzles: %w", err)
+	}
+	defer rows.Close()
+
+	var puzzles []int
+	for rows.Next() {
+		var puzzle int
+		if err := rows.Scan(&puzzle); err != nil {
+			return nil, fmt.Errorf("scanning processed puzzle: %w", err)
+		}
+		puzzles = append(puzzles, puzzle)
+	}
+	return puzzles, rows.Err()
+}
+
+// "512 B", "4.0 KB", "1.5 MB", ...
+func formatBytes(n int64) string {
+	if n < 1024 {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, unit := float64(n)/1024, "KB"
+	for _, next := range []string{"MB", "GB"} {
+		if value < 1024 {
+			break
+		}
+		value, unit = value/1024, next
+	}
+	return fmt.Sprintf("%.1f %s", value, unit)
+}
+
+// "3d 4h 12m", or "4h 12m" and "12m" when shorter
+func formatUptime(d time.Duration) string {
+	days := int(d.Hours()) / 24
+	hours := int(d.Hours()) % 24
+	minutes := int(d.Minutes()) % 60
+	switch {
+	case days > 0:
+		return fmt.Sprintf("%dd %dh %dm", days, hours, minutes)
+	case hours > 0:
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	default:
+		return fmt.Sprintf("%dm", minutes)
+	}
+}
+
+// Build the !status health overview of a board: the puzzle range processed,
+// any puzzle numbers missing from it, days recorded, database size and
+// uptime
+func (b *Bot) buildStatus(bd board) (string, error) {
+	puzzles, err := b.processedPuzzles(bd)
+	if err != nil {
+		return "", err
+	}
+	var days int
+	var first, last string
+	err = b.db.QueryRow("SELECT COUNT(*), COALESCE(MIN(result_date), ''), COALESCE(MAX(result_date), '') FROM processed_days WHERE guild_id = ? AND channel_id = ? AND game = ?", bd.guildID, bd.channelID, bd.game).Scan(&days, &first, &last)
+	if err != nil {
+		return "", fmt.Errorf("counting processed days: %w", err)
+	}
+	size, err := b.store.Size()
+	if err != nil {
+		return "", err
+	}
+
+	output := "🩺 **Bot Status**\n"
+	if len(puzzles) > 0 {
+		output += fmt.Sprintf("Puzzles processed: Wordle %d to %d\n", puzzles[0], puzzles[len(puzzles)-1])
+	} else {
+		output += "Puzzles processed: none with a puzzle number yet\n"
+	}
+	if days > 0 {
+		output += fmt.Sprintf("Days recorded: %d (%s to %s)\n", days, first, last)
+	} else {
+		output += "Days recorded: 0\n"
+	}
+
+	if gaps := puzzleGaps(puzzles); len(gaps) > 0 {
+		missing := 0
+		names := make([]string, 0, statusGapLimit)
+		for i, g := range gaps {
+			missing += g.size()
+			if i < statusGapLimit {
+				names = append(names, g.String())
+			}
+		}
+		if len(gaps) > statusGapLimit {
+			names = append(names, fmt.Sprintf("and %d more", len(gaps)-statusGapLimit))
+		}
+		output += fmt.Sprintf("⚠️ Missing puzzles: %d (%s)\n", missing, strings.Join(names, ", "))
+	} else if len(puzzles) > 0 {
+		output += "No puzzles missed ✅\n"
+	}
+
+	output += fmt.Sprintf("Database size: %s\n", formatBytes(size))
+	output += fmt.Sprintf("Uptime: %s", formatUptime(time.Since(b.startedAt)))
+	return output, nil
+}
+
+// Handle "!status": report whether the bot has been keeping up
+func (b *Bot) handleStatusCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	if !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can check the bot's status.")
+		return
+	}
+	output, err := b.buildStatus(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error building status", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
+
+// Longest gap list !gaps sends, leaving room under Discord's message limit
+const gapsTextLimit = 1800
+
+// Build the !gaps reply: every puzzle number missing between the first and
+// last processed puzzle, runs shortened to "1210–1212"
+func (b *Bot) buildGaps(bd board) (string, error) {
+	puzzles, err := b.processedPuzzles(bd)
+	if err != nil {
+		return "", err
+	}
+	if len(puzzles) == 0 {
+		return "No puzzles with a puzzle number have been processed yet.", nil
+	}
+	gaps := puzzleGaps(puzzles)
+	if len(gaps) == 0 {
+		return fmt.Sprintf("No gaps: every puzzle from Wordle %d to %d was processed ✅", puzzles[0], puzzles[len(puzzles)-1]), nil
+	}
+
+	missing := 0
+	for _, g := range gaps {
+		missing += g.size()
+	}
+	list := ""
+	for i, g := range gaps {
+		if len(list) > gapsTextLimit {
+			list += fmt.Sprintf(" and %d more", len(gaps)-i)
+			break
+		}
+		if i > 0 {
+			list += ", "
+		}
+		list += g.String()
+	}
+	return fmt.Sprintf("Between Wordle %d and %d, %d puzzle(s) were never processed.\nMissing: %s\nThey can be backfilled with `-import`.", puzzles[0], puzzles[len(puzzles)-1], missing, list), nil
+}
+
+// Handle "!gaps": list the puzzle numbers missing from the tracked range
+func (b *Bot) handleGapsCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	output, err := b.buildGaps(b.boardFor(m.GuildID, m.ChannelID))
+	if err != nil {
+		slog.Error("Error finding puzzle gaps", "err", err)
+		return
+	}
+	sendMessage(s, m.ChannelID, output)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// The backends DB_DRIVER can select
+const (
+	driverSQLite   = "sqlite"
+	driverPostgres = "postgres"
+)
+
+// Read DB_DRIVER, sqlite unless set
+func dbDriver() string {
+	if driver := strings.ToLower(strings.TrimSpace(os.Getenv("DB_DRIVER"))); driver != "" {
+		return driver
+	}
+	return driverSQLite
+}
+
+// Connect to the backend DB_DRIVER selects: SQLite at DATABASE_PATH, so it
+// can live on a mounted volume, or Postgres at DATABASE_URL
+func openStore() (store, error) {
+	if dbDriver() == driverPostgres {
+		pool, err := loadPoolSettings()
+		if err != nil {
+			return nil, err
+		}
+		db, err := openPostgres(strings.TrimSpace(os.Getenv("DATABASE_URL")), pool)
+		if err != nil {
+			return nil, fmt.Errorf("connecting to Postgres: %w", err)
+		}
+		slog.Info("Using database", "driver", driverPostgres, "max_open_conns", pool.maxOpen, "max_idle_conns", pool.maxIdle, "conn_max_lifetime", pool.maxLifetime)
+		return newPostgresStore(db), nil
+	}
+
+	path := strings.TrimSpace(os.Getenv("DATABASE_PATH"))
+	if path == "" {
+		path = defaultDatabasePath
+	}
+	db, err := openDatabase(path)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to database %s: %w", path, err)
+	}
+	slog.Info("Using database", "driver", driverSQLite, "path", path)
+	return newSQLiteStore(db), nil
+}
+
+// The database behind the bot, one implementation per DB_DRIVER. Queries
+// that read the same on every backend run on DB() directly; a store holds
+// the leaderboard's core reads and writes and whatever differs between
+// backends.
+type store interface {
+	// The connection pool every other query runs on
+	DB() *sql.DB
+
+	// A CREATE TABLE statement rewritten for this backend
+	Schema(create string) string
+
+	// Bytes the database takes up, for !status
+	Size() (int64, error)
+
+	// Prepare the store's statements; call after the tables exist
+	Prepare() error
+
+	// Add one day to a player's row on a board, creating it on their first
+	UpdateScore(tx *sql.Tx, bd board, userID string, u scoreUpdate) error
+
+	// A board's players for a period, best first under the scoring's order
+	// for the period's sort mode (before head-to-head tie breaks)
+	TopPlayers(bd board, period leaderboardPeriod, sc scoring) ([]leaderboardEntry, error)
+
+	// A player's stored record on a board without their rank, nil if they
+	// have never played on it
+	UserStats(bd board, userID string) (*userStats, error)
+}
+
+// What one processed day changes on a player's leaderboard row
+type scoreUpdate struct {
+	displayName string // refreshes the cached username, empty keeps it
+	score       int    // added to the total
+	played      bool   // a day played; penalties leave last_played, joined_date and active alone
+	failed      bool
+	date        string
+	streak      int // current and best streak after the day
+	maxStreak   int
+}
+
+// The part of a store written in SQL both backends run unchanged
+type sqlStore struct {
+	db          *sql.DB
+	updateScore *sql.Stmt
+}
+
+func (s *sqlStore) DB() *sql.DB {
+	return s.db
+}
+
+// Relies on idx_leaderboard_board_game_user, which initializeDatabase
+// creates. Played days move last_played on, joined_date back for an earlier
+// day processed late, and make a player who had left active again.
+const updateScoreSQL = `
+    INSERT INTO leaderboard (guild_id, channel_id, game, user_id, username, score, days_played, current_streak, max_streak, fails, last_played, joined_date, active)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1)
+    ON CONFLICT (guild_id, channel_id, game, user_id) DO UPDATE SET
+        username = COALESCE(NULLIF(excluded.username, ''), leaderboard.username),
+        score = leaderboard.score + excluded.score,
+        days_played = leaderboard.days_played + excluded.days_played,
+        current_streak = excluded.current_streak,
+        max_streak = excluded.max_streak,
+        fails = leaderboard.fails + excluded.fails,
+        last_played = CASE WHEN excluded.last_played > leaderboard.last_played THEN excluded.last_played ELSE leaderboard.last_played END,
+        joined_date = CASE WHEN excluded.joined_date <> '' AND (leaderboard.joined_date = '' OR excluded.joined_date < leaderboard.joined_date)
+                           THEN excluded.joined_date ELSE leaderboard.joined_date END,
+        active = CASE WHEN excluded.days_played > 0 THEN 1 ELSE leaderboard.active END`
+
+func (s *sqlStore) Prepare() error {
+	stmt, err := s.db.Prepare(updateScoreSQL)
+	if err != nil {
+		return fmt.Errorf("preparing score update: %w", err)
+	}
+	s.updateScore = stmt
+	return nil
+}
+
+func (s *sqlStore) UpdateScore(tx *sql.Tx, bd board, userID string, u scoreUpdate) error {
+	days, failed, lastPlayed := 0, 0, ""
+	if u.played {
+		days, lastPlayed = 1, u.date
+	}
+	if u.failed {
+		failed = 1
+	}
+	_, err := tx.Stmt(s.updateScore).Exec(bd.guildID, bd.channelID, bd.game, userID, u.displayName, u.score, days, u.streak, u.maxStreak, failed, lastPlayed, lastPlayed)
+	return err
+}
+
+// Leaderboard rows (user ID, cached username, total score, days played,
+// days solved, current and best streak, last played date) for a period,
+// ordered by its sort mode. The last played date is the all-time one on
+// every period. The order ranks by expressions over the selected columns,
+// so the rows are picked in a subquery first.
+func (s *sqlStore) TopPlayers(bd board, period leaderboardPeriod, sc scoring) ([]leaderboardEntry, error) {
+	order := sc.leaderboardOrder(period.sort)
+	var rows *sql.Rows
+	var err error
+	if period.since == "" && period.until == "" {
+		rows, err = s.db.Query(`
+    SELECT * FROM (
+        SELECT l.user_id AS player, l.username, l.score AS total, l.days_played AS days,
+               (SELECT COUNT(*) FROM daily_results d
+                WHERE d.guild_id = l.guild_id AND d.channel_id = l.channel_id AND d.game = l.game AND d.user_id = l.user_id
+                  AND d.played = 1 AND d.failed = 0) AS wins,
+               l.current_streak AS streak, l.max_streak AS best_streak, l.last_played
+        FROM leaderboard l
+        WHERE l.guild_id = ? AND l.channel_id = ? AND l.game = ? AND l.days_played > 0 AND l.active = 1
+          AND l.user_id NOT IN (SELECT user_id FROM excluded_users WHERE guild_id = ?)
+    ) players
+    ORDER BY `+order, bd.guildID, bd.channelID, bd.game, bd.guildID)
+	} else {
+		// Windowed and as-of leaderboards are summed from the per-day
+		// results; streaks are always the current ones
+		rows, err = s.db.Query(`
+    SELECT * FROM (
+        SELECT d.user_id AS player, COALESCE(MAX(l.username), '') AS username, SUM(d.`+sc.dailyColumn()+`) AS total, SUM(d.played) AS days,
+               SUM(d.played) - SUM(d.failed) AS wins, COALESCE(MAX(l.current_streak), 0) AS streak,
+               COALESCE(MAX(l.max_streak), 0) AS best_streak, COALESCE(MAX(l.last_played), '') AS last_played
+        FROM daily_results d
+        LEFT JOIN leaderboard l ON l.guild_id = d.guild_id AND l.channel_id = d.channel_id AND l.game = d.game AND l.user_id = d.user_id
+        WHERE d.guild_id = ? AND d.channel_id = ? AND d.game = ? AND d.result_date >= ? AND (? = '' OR d.result_date < ?)
+          AND COALESCE(l.active, 1) = 1
+          AND d.user_id NOT IN (SELECT user_id FROM excluded_users WHERE guild_id = ?)
+        GROUP BY d.user_id
+        HAVING SUM(d.played) > 0
+    ) players
+    ORDER BY `+order, bd.guildID, bd.channelID, bd.game, period.since, period.until, period.until, bd.guildID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []leaderboardEntry
+	for rows.Next() {
+		var e leaderboardEntry
+		var bestStreak int
+		if err := rows.Scan(&e.userID, &e.username, &e.totalScore, &e.daysPlayed, &e.wins, &e.streak, &bestStreak, &e.lastPlayed); err != nil {
+			slog.Error("Error scanning leaderboard row", "err", err)
+			continue
+		}
+		// The queries already leave these out; an average over no days
+		// can't be ranked
+		if e.daysPlayed <= 0 {
+			slog.Debug("Skipping leaderboard row without days played", "user", e.userID)
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
+
+func (s *sqlStore) UserStats(bd board, userID string) (*userStats, error) {
+	var st userStats
+	err := s.db.QueryRow("SELECT score, days_played, fails FROM leaderboard WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ?", bd.guildID, bd.channelID, bd.game, userID).Scan(&st.totalScore, &st.daysPlayed, &st.fails)
+	if err == sql.ErrNoRows || (err == nil && st.daysPlayed == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Best and worst scored days (absence penalties excluded). Fails are
+	// stored with the configured penalty, so map them back to failScore.
+	err = s.db.QueryRow(`
+    SELECT MIN(CASE WHEN failed = 1 THEN CAST(? AS INTEGER) ELSE score END), MAX(CASE WHEN failed = 1 THEN CAST(? AS INTEGER) ELSE score END)
+    FROM daily_results
+    WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND played = 1`, failScore, failScore, bd.guildID, bd.channelID, bd.game, userID).Scan(&st.best, &st.worst)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
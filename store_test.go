@@ -0,0 +1,128 @@
+package main
+
+import (
+	"database/sql/driver"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"SELECT * FROM t WHERE a = ? AND b = ?", "SELECT * FROM t WHERE a = $1 AND b = $2"},
+		{"SELECT '?' FROM t WHERE a = ?", "SELECT '?' FROM t WHERE a = $1"},
+		{"SELECT 'it''s?' FROM t WHERE a = ?", "SELECT 'it''s?' FROM t WHERE a = $1"},
+		{`SELECT "odd?name" FROM t WHERE a = ?`, `SELECT "odd?name" FROM t WHERE a = $1`},
+		{"VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)", "VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)"},
+	}
+	for _, tt := range tests {
+		if got := rebind(tt.query); got != tt.want {
+			t.Errorf("rebind(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestRebindConnStoresBoolsAsIntegers(t *testing.T) {
+	tests := []struct {
+		value any
+		want  any
+	}{
+		{true, int64(1)},
+		{false, int64(0)},
+		{int64(7), int64(7)},
+		{"yes", "yes"},
+	}
+	for _, tt := range tests {
+		nv := driver.NamedValue{Ordinal: 1, Value: tt.value}
+		if err := (rebindConn{}).CheckNamedValue(&nv); err != nil {
+			t.Fatalf("CheckNamedValue(%v): %v", tt.value, err)
+		}
+		if nv.Value != tt.want {
+			t.Errorf("CheckNamedValue(%v) = %#v, want %#v", tt.value, nv.Value, tt.want)
+		}
+	}
+}
+
+func TestDatabaseSettingsChecked(t *testing.T) {
+	tests := []struct {
+		name              string
+		driver, url, path string
+		want              string // part of the problem reported, empty for none
+	}{
+		{"default", "", "", "", ""},
+		{"sqlite", "SQLite", "", "./data/leaderboard.db", ""},
+		{"postgres", "postgres", "postgres://bot:secret@db:5432/wordle", "", ""},
+		{"unknown driver", "mysql", "", "", `DB_DRIVER "mysql" is not supported`},
+		{"postgres without a URL", "postgres", "", "", "DATABASE_URL is not set"},
+		{"invalid URL", "postgres", "postgres://db:notaport/wordle", "", "DATABASE_URL is not a valid"},
+		{"URL without the driver", "", "postgres://bot:secret@db:5432/wordle", "", "DB_DRIVER is not postgres"},
+		{"path with postgres", "postgres", "postgres://bot:secret@db:5432/wordle", "./leaderboard.db", "DATABASE_PATH is set"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_DRIVER", tt.driver)
+			t.Setenv("DATABASE_URL", tt.url)
+			t.Setenv("DATABASE_PATH", tt.path)
+			problems := slices.DeleteFunc(checkEnvironment(true), func(p string) bool {
+				return !strings.Contains(p, "DB_DRIVER") && !strings.Contains(p, "DATABASE_")
+			})
+			switch {
+			case tt.want == "" && len(problems) > 0:
+				t.Errorf("problems = %q, want none", problems)
+			case tt.want != "" && (len(problems) != 1 || !strings.Contains(problems[0], tt.want)):
+				t.Errorf("problems = %q, want one mentioning %q", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadPoolSettings(t *testing.T) {
+	tests := []struct {
+		name                 string
+		open, idle, lifetime string
+		want                 poolSettings
+		wantErr              bool
+	}{
+		{"unset", "", "", "", defaultPoolSettings, false},
+		{"all set", "10", "5", "30m", poolSettings{maxOpen: 10, maxIdle: 5, maxLifetime: 30 * time.Minute}, false},
+		{"zero lifetime", "", "", "0", defaultPoolSettings, false},
+		{"no idle connections", "", "0", "", poolSettings{}, false},
+		{"negative count", "-1", "", "", poolSettings{}, true},
+		{"not a number", "", "lots", "", poolSettings{}, true},
+		{"lifetime without a unit", "", "", "30", poolSettings{}, true},
+		{"negative lifetime", "", "", "-5m", poolSettings{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("DB_MAX_OPEN_CONNS", tt.open)
+			t.Setenv("DB_MAX_IDLE_CONNS", tt.idle)
+			t.Setenv("DB_CONN_MAX_LIFETIME", tt.lifetime)
+			got, err := loadPoolSettings()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("loadPoolSettings() = %+v, want an error", got)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("loadPoolSettings() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestPoolSettingsRejectedForSQLite(t *testing.T) {
+	t.Setenv("DB_DRIVER", "")
+	t.Setenv("DATABASE_URL", "")
+	t.Setenv("DB_MAX_OPEN_CONNS", "10")
+	if problems := checkEnvironment(true); !slices.ContainsFunc(problems, func(p string) bool {
+		return strings.Contains(p, "DB_MAX_OPEN_CONNS")
+	}) {
+		t.Errorf("problems = %q, want one about DB_MAX_OPEN_CONNS under SQLite", problems)
+	}
+}
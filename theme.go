@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Default embed colour (Wordle green)
+const leaderboardColor = 0x6AAA64
+
+// How the leaderboard looks: LEADERBOARD_MEDALS, LEADERBOARD_COLOR and
+// STREAK_EMOJIS
+type theme struct {
+	medals     [3]string // for ranks 1 to 3
+	color      int       // side colour of embeds
+	milestones []streakMilestone
+}
+
+// An emoji shown next to players on a current streak of at least days
+type streakMilestone struct {
+	days  int
+	emoji string
+}
+
+// The built-in look: gold, silver and bronze medals, no streak emojis
+func defaultTheme() theme {
+	return theme{medals: [3]string{"🥇", "🥈", "🥉"}, color: leaderboardColor}
+}
+
+// Custom Discord emoji markup, such as "<:fire:123>" or "<a:party:123>"
+var customEmojiRegex = regexp.MustCompile(`^<a?:\w{2,32}:\d+>$`)
+
+// Check that a setting holds a single emoji: custom emoji markup, or a
+// short run of characters with at least one symbol and no spaces
+func isEmoji(s string) bool {
+	if customEmojiRegex.MatchString(s) {
+		return true
+	}
+	if s == "" || utf8.RuneCountInString(s) > 8 {
+		return false
+	}
+	symbol := false
+	for _, r := range s {
+		if unicode.IsSpace(r) || unicode.IsLetter(r) {
+			return false
+		}
+		symbol = symbol || unicode.In(r, unicode.So, unicode.Me) // Me for keycaps like 1️⃣
+	}
+	return symbol
+}
+
+// Read the theme from the environment, keeping the default for anything
+// unset:
+//
+//	LEADERBOARD_MEDALS=🏆,🎖️,🏅       medals for ranks 1 to 3
+//	LEADERBOARD_COLOR=#6AAA64         embed colour as hex
+//	STREAK_EMOJIS=5:🔥,30:💎           emoji next to streaks of 5+ and 30+ days
+func loadTheme() (theme, error) {
+	t := defaultTheme()
+
+	if raw := strings.TrimSpace(os.Getenv("LEADERBOARD_MEDALS")); raw != "" {
+		medals := strings.Split(raw, ",")
+		if len(medals) != len(t.medals) {
+			return t, fmt.Errorf("LEADERBOARD_MEDALS must list 3 emojis separated by commas, got %q", raw)
+		}
+		for i, medal := range medals {
+			medal = strings.TrimSpace(medal)
+			if !isEmoji(medal) {
+				return t, fmt.Errorf("LEADERBOARD_MEDALS contains %q, which is not an emoji", medal)
+			}
+			t.medals[i] = medal
+		}
+	}
+
+	if raw := strings.TrimSpace(os.Getenv("LEADERBOARD_COLOR")); raw != "" {
+		hex := strings.TrimPrefix(strings.TrimPrefix(strings.ToLower(raw), "#"), "0x")
+		color, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return t, fmt.Errorf("LEADERBOARD_COLOR must be a hex colour like #6AAA64, got %q", raw)
+		}
+		t.color = int(color)
+	}
+
+	if raw := strings.TrimSpace(os.Getenv("STREAK_EMOJIS")); raw != "" {
+		for _, pair := range strings.Split(raw, ",") {
+			days, emoji, ok := strings.Cut(strings.TrimSpace(pair), ":")
+			n, err := strconv.Atoi(strings.TrimSpace(days))
+			emoji = strings.TrimSpace(emoji)
+			if !ok || err != nil || n <= 0 || !isEmoji(emoji) {
+				return t, fmt.Errorf("STREAK_EMOJIS entries must look like 5:🔥, got %q", pair)
+			}
+			t.milestones = append(t.milestones, streakMilestone{days: n, emoji: emoji})
+		}
+		// Longest first, so the first one reached is the one shown
+		sort.Slice(t.milestones, func(i, j int) bool { return t.milestones[i].days > t.milestones[j].days })
+	}
+	return t, nil
+}
+
+// Medal for the top three ranks, "N." for everyone else
+func (t theme) medal(rank int) string {
+	if rank >= 1 && rank <= len(t.medals) {
+		return t.medals[rank-1]
+	}
+	return fmt.Sprintf("%d.", rank)
+}
+
+// The emoji of the longest streak milestone reached, "" if none
+func (t theme) streakEmoji(streak int) string {
+	for _, m := range t.milestones {
+		if streak >= m.days {
+			return m.emoji
+		}
+	}
+	return ""
+}
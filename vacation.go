@@ -0,0 +1,130 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Longest vacation !vacation grants at once
+const vacationMaxDays = 60
+
+// Whether a user is on vacation on a date in a guild, which exempts them
+// from the miss penalty on every board
+func onVacation(tx *sql.Tx, guildID, userID, date string) (bool, error) {
+	var exists int
+	err := tx.QueryRow("SELECT 1 FROM vacations WHERE guild_id = ? AND user_id = ? AND start_date <= ? AND end_date >= ? LIMIT 1", guildID, userID, date, date).Scan(&exists)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("checking vacation of %s: %w", userID, err)
+	}
+	return true, nil
+}
+
+// The day a streak continues from when a user plays on date: the day
+// before, skipping back over vacation days they didn't play, so that time
+// away doesn't break a streak
+func streakPreviousDate(tx *sql.Tx, bd board, userID, date string) (string, error) {
+	previous := previousDate(date)
+	for previous != "" {
+		away, err := onVacation(tx, bd.guildID, userID, previous)
+		if err != nil || !away {
+			return previous, err
+		}
+		var played int
+		err = tx.QueryRow("SELECT COUNT(*) FROM daily_results WHERE guild_id = ? AND channel_id = ? AND game = ? AND user_id = ? AND result_date = ? AND played = 1", bd.guildID, bd.channelID, bd.game, userID, previous).Scan(&played)
+		if err != nil {
+			return "", fmt.Errorf("checking result of %s: %w", userID, err)
+		}
+		if played > 0 {
+			return previous, nil
+		}
+		previous = previousDate(previous)
+	}
+	return previous, nil
+}
+
+// Put a user on vacation from today for days days, replacing any vacation
+// not over yet; 0 days ends it. Returns the last day of the vacation.
+func (b *Bot) setVacation(guildID, userID, setBy string, days int) (string, error) {
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+
+	today := b.now()
+	tx, err := b.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("starting vacation update: %w", err)
+	}
+	defer tx.Rollback() // No-op once committed
+
+	// Days already past stay covered, so their penalties aren't owed later
+	yesterday := today.AddDate(0, 0, -1).Format(dateLayout)
+	_, err = tx.Exec("DELETE FROM vacations WHERE guild_id = ? AND user_id = ? AND start_date > ?", guildID, userID, yesterday)
+	if err != nil {
+		return "", fmt.Errorf("clearing upcoming vacation: %w", err)
+	}
+	_, err = tx.Exec("UPDATE vacations SET end_date = ? WHERE guild_id = ? AND user_id = ? AND end_date > ?", yesterday, guildID, userID, yesterday)
+	if err != nil {
+		return "", fmt.Errorf("ending current vacation: %w", err)
+	}
+
+	end := ""
+	if days > 0 {
+		end = today.AddDate(0, 0, days-1).Format(dateLayout)
+		_, err = tx.Exec("INSERT INTO vacations (guild_id, user_id, start_date, end_date, set_by) VALUES (?, ?, ?, ?, ?)", guildID, userID, today.Format(dateLayout), end, setBy)
+		if err != nil {
+			return "", fmt.Errorf("recording vacation: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("committing vacation: %w", err)
+	}
+	return end, nil
+}
+
+// Handle "!vacation [@user] <days>": skip miss penalties from today for a
+// number of days, without breaking streaks. Anyone can go on vacation
+// themselves; putting someone else on vacation takes an admin. 0 days ends
+// a vacation early.
+func (b *Bot) handleVacationCommand(s *discordgo.Session, m *discordgo.MessageCreate) {
+	args := commandArgs(m.Content)
+	userID := m.Author.ID
+	if len(args) == 2 {
+		userID = cleanUsername(args[0])
+		args = args[1:]
+	}
+	days := -1
+	if len(args) == 1 {
+		if n, err := strconv.Atoi(args[0]); err == nil {
+			days = n
+		}
+	}
+	if days < 0 || days > vacationMaxDays {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Usage: `%svacation [@user] <days>` with up to %d days, or 0 to end a vacation", b.prefix, vacationMaxDays))
+		return
+	}
+	if userID != m.Author.ID && !b.isAdmin(s, m) {
+		sendMessage(s, m.ChannelID, "Only admins can put someone else on vacation.")
+		return
+	}
+
+	end, err := b.setVacation(m.GuildID, userID, m.Author.ID, days)
+	if err != nil {
+		slog.Error("Error setting vacation", "err", err)
+		sendMessage(s, m.ChannelID, "Failed to update the vacation, please try again.")
+		return
+	}
+	slog.Info("Vacation updated", "guild", m.GuildID, "user", userID, "by", m.Author.ID, "days", days)
+	if end == "" {
+		sendMessage(s, m.ChannelID, fmt.Sprintf("Welcome back %s, missed days count again.", mention(userID)))
+		return
+	}
+	last, _ := time.Parse(dateLayout, end)
+	sendMessage(s, m.ChannelID, fmt.Sprintf("🏖️ %s is on vacation through %s: no miss penalties, and streaks pick up where they left off.", mention(userID), last.Format("Mon Jan 2")))
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Give the WINNER_ROLE_ID role to the all-time leader of a board, taking it
+// from whoever held it. When players tie for first the holder keeps it if
+// they're among them. The role belongs to the guild, so with per-channel
+// leaderboards it goes to the leader of the board processed last. Failures
+// are logged rather than reported in the channel, and the holder is only
+// saved once the role was added.
+func (b *Bot) updateWinnerRole(s *discordgo.Session, bd board) {
+	if b.winnerRoleID == "" || bd.guildID == "" {
+		return
+	}
+	entries, err := b.leaderboardEntries(bd, allTimePeriod)
+	if err != nil {
+		slog.Error("Error loading standings for the winner role", "err", err)
+		return
+	}
+	if len(entries) == 0 {
+		return
+	}
+	holder, roleID, err := b.winnerRoleHolder(bd.guildID)
+	if err != nil {
+		slog.Error("Error loading the winner role holder", "err", err)
+		return
+	}
+
+	leader := entries[0].userID
+	for i, rank := range competitionRanks(entries) {
+		if rank > 1 {
+			break
+		}
+		if entries[i].userID == holder {
+			leader = holder
+		}
+	}
+	if leader == holder && roleID == b.winnerRoleID {
+		return
+	}
+
+	// Added first, so the current holder keeps it if that fails
+	if err := s.GuildMemberRoleAdd(bd.guildID, leader, b.winnerRoleID); err != nil {
+		logRoleError("Error giving the winner role", bd.guildID, leader, b.winnerRoleID, err)
+		return
+	}
+	if holder != "" {
+		// A holder who left the server has nothing to take back
+		if err := s.GuildMemberRoleRemove(bd.guildID, holder, roleID); err != nil && !isRESTStatus(err, http.StatusNotFound) {
+			logRoleError("Error taking back the winner role", bd.guildID, holder, roleID, err)
+		}
+	}
+
+	b.writeMu.Lock()
+	defer b.writeMu.Unlock()
+	_, err = b.db.Exec("INSERT INTO winner_roles (guild_id, role_id, user_id) VALUES (?, ?, ?) ON CONFLICT (guild_id) DO UPDATE SET role_id = excluded.role_id, user_id = excluded.user_id", bd.guildID, b.winnerRoleID, leader)
+	if err != nil {
+		slog.Error("Error saving the winner role holder", "err", err)
+		return
+	}
+	slog.Info("Passed on the winner role", "guild", bd.guildID, "role", b.winnerRoleID, "from", holder, "to", leader)
+}
+
+// Who holds the winner role in a guild and which role it was, "" if nobody
+func (b *Bot) winnerRoleHolder(guildID string) (userID, roleID string, err error) {
+	err = b.db.QueryRow("SELECT user_id, role_id FROM winner_roles WHERE guild_id = ?", guildID).Scan(&userID, &roleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", "", nil
+	}
+	return userID, roleID, err
+}
+
+// Log a failed role change, explaining the usual cause when Discord refused it
+func logRoleError(msg, guildID, userID, roleID string, err error) {
+	if isRESTStatus(err, http.StatusForbidden) {
+		slog.Warn(msg+": missing permission, the bot needs Manage Roles and a role above the winner role", "guild", guildID, "user", userID, "role", roleID, "err", err)
+		return
+	}
+	slog.Error(msg, "guild", guildID, "user", userID, "role", roleID, "err", err)
+}
+
+// Report whether a Discord request failed with the given HTTP status
+func isRESTStatus(err error, status int) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == status
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// An account results messages are accepted from: a user ID, or a username
+// and discriminator ("0" for accounts on Discord's newer unique usernames)
+type botIdentity struct {
+	id            string
+	username      string
+	discriminator string
+}
+
+// The official Wordle bot, accepted when no identities are configured
+var defaultWordleBots = []botIdentity{{username: "Wordle", discriminator: "2092"}}
+
+// "123456789012345678", "Wordle#2092" or "mockwordle"
+func (i botIdentity) String() string {
+	switch {
+	case i.id != "":
+		return i.id
+	case i.discriminator == "0":
+		return i.username
+	default:
+		return i.username + "#" + i.discriminator
+	}
+}
+
+// Whether a message author is this account
+func (i botIdentity) matches(u *discordgo.User) bool {
+	if i.id != "" {
+		return u.ID == i.id
+	}
+	discriminator := u.Discriminator
+	if discriminator == "" {
+		discriminator = "0"
+	}
+	return u.Username == i.username && discriminator == i.discriminator
+}
+
+// Parse one WORDLE_BOTS entry: a user ID, "name#1234", or a bare name for an
+// account without a discriminator
+func parseBotIdentity(entry string) (botIdentity, error) {
+	if isUserID(entry) {
+		return botIdentity{id: entry}, nil
+	}
+	name, discriminator, tagged := strings.Cut(entry, "#")
+	if !tagged {
+		discriminator = "0"
+	} else if len(discriminator) != 4 || strings.Trim(discriminator, "0123456789") != "" {
+		return botIdentity{}, fmt.Errorf("WORDLE_BOTS entry %q needs a 4 digit discriminator after the #", entry)
+	}
+	if name == "" {
+		return botIdentity{}, fmt.Errorf("WORDLE_BOTS entry %q has no username", entry)
+	}
+	return botIdentity{username: name, discriminator: discriminator}, nil
+}
+
+// Read WORDLE_BOTS, a comma separated allowlist of the accounts posting
+// results, plus the older single WORDLE_BOT_USER_ID. Without either the
+// official Wordle#2092 is accepted.
+func loadWordleBots() ([]botIdentity, error) {
+	var bots []botIdentity
+	for _, entry := range strings.Split(os.Getenv("WORDLE_BOTS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		bot, err := parseBotIdentity(entry)
+		if err != nil {
+			return nil, err
+		}
+		bots = append(bots, bot)
+	}
+	if id := strings.TrimSpace(os.Getenv("WORDLE_BOT_USER_ID")); id != "" {
+		bots = append(bots, botIdentity{id: id})
+	}
+	if len(bots) == 0 {
+		return defaultWordleBots, nil
+	}
+	return bots, nil
+}